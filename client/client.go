@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"sort"
 	"sync"
 	"syscall/js"
 
@@ -58,6 +60,10 @@ func newClient() *client {
 			inp.Down.Down()
 		case "Space":
 			inp.Fire.Down()
+		case "KeyX":
+			inp.Secondary.Down()
+		case "KeyZ":
+			inp.SwitchWeapon.Down()
 		}
 		return nil
 	}))
@@ -74,6 +80,12 @@ func newClient() *client {
 			inp.Down.Up()
 		case "Space":
 			inp.Fire.Up()
+		case "KeyX":
+			inp.Secondary.Up()
+		case "KeyZ":
+			inp.SwitchWeapon.Up()
+		case "F3":
+			debugOverlay.toggle()
 		}
 		return nil
 	}))
@@ -86,7 +98,7 @@ func newClient() *client {
 
 	c := &client{
 		gr:            gr,
-		g:             game.NewGame(),
+		g:             newGame(),
 		inp:           inp,
 		lastTimestamp: js.Global().Get("performance").Call("now").Float(),
 	}
@@ -130,6 +142,14 @@ func newClient() *client {
 	return c
 }
 
+// newGame creates a game which runs in the same fixed ticks as the server, so
+// that the ticks in the tracks it sends are the same length as everyone else's.
+func newGame() *game.Game {
+	g := game.NewGame()
+	g.FixedDt = game.TickDt
+	return g
+}
+
 type client struct {
 	gr            *graphics
 	grLock        sync.Mutex
@@ -170,7 +190,8 @@ func (c *client) connect(addr string) {
 			setOverlay("")
 			c.inp.IsConnected = true
 			c.inp.Cid = clientInitialize.Cid
-			c.g = game.NewGame()
+			c.g = newGame()
+			c.g.ServerAuthoritative = clientInitialize.ServerAuthoritative
 
 			if c.sending != nil {
 				close(c.sending)
@@ -251,11 +272,12 @@ func (c *client) scheduleFrame() {
 		}
 
 		c.frame()
+		debugOverlay.update(now, c.g.Profiler)
 
 		// Currently sending to server, which sends back to client.
 		// selfSend := []*pb.Memo{}
 		// for _, memo := range c.inp.MemosOut {
-		// 	if isMemoRecipient(c.inp.Cid, memo) {
+		// 	if isMemoRecipient(c.inp.Cid, team, memo) {
 		// 		selfSend = append(selfSend, memo)
 		// 	}
 		// 	combineToSend(c.receiving, selfSend)
@@ -298,11 +320,11 @@ func (c *client) frame() {
 		yMax := float32(15)
 
 		for i.Next() {
-			x := (*i.Pos())[0]
-			y := (*i.Pos())[1]
+			x := (*game.PosKey.Get(i))[0]
+			y := (*game.PosKey.Get(i))[1]
 			boundary := float32(0)
 
-			sprite := i.Sprite()
+			sprite := game.SpriteKey.Get(i)
 			if sprite != nil {
 				boundary = spritemap[*sprite].size * 2
 			}
@@ -329,7 +351,7 @@ func (c *client) frame() {
 		i.Require(game.PosKey)
 		i.Require(game.PointRenderKey)
 		for i.Next() {
-			p := *i.Pos()
+			p := *game.PosKey.Get(i)
 			c.gr.Point(p[0], p[1])
 		}
 	}
@@ -339,21 +361,26 @@ func (c *client) frame() {
 		i.Require(game.PosKey)
 		i.Require(game.SpriteKey)
 		for i.Next() {
-			p := *i.Pos()
-			rot := i.Rot()
+			p := *game.PosKey.Get(i)
+			rot := game.RotKey.Get(i)
 			rotation := float32(0)
 			if rot != nil {
 				rotation = *rot
 			}
-			c.gr.Sprite(spritemap[*i.Sprite()], p[0], p[1], rotation)
+			c.gr.Sprite(spritemap[*game.SpriteKey.Get(i)], p[0], p[1], rotation)
 		}
 	}
 
 	c.gr.Flush()
 
+	hud.update(c.g, c.inp.Cid)
+
 	c.inp.FrameEndReset()
 
-	if c.g.ControlledShip.Alive() {
+	if c.g.Match.Ended() {
+		results.update(c.g, c.inp.Cid)
+		setOverlay("overlay-results")
+	} else if c.g.E.Alive(c.g.ControlledShip) {
 		c.tutorial.timeAlive += c.inp.Dt
 		if c.inp.Left.Hold || c.inp.Right.Hold {
 			c.tutorial.timeTurning += c.inp.Dt
@@ -392,6 +419,7 @@ var overlays = map[string]js.Value{
 	"overlay-tutorial-turn":  js.Null(),
 	"overlay-tutorial-move":  js.Null(),
 	"overlay-tutorial-shoot": js.Null(),
+	"overlay-results":        js.Null(),
 }
 
 func init() {
@@ -424,6 +452,132 @@ func setOverlay(id string) {
 	}
 }
 
+// hud shows the match timer, the health, shield and secondary weapon of the
+// player's ship, and their score.
+var hud = &hudText{}
+
+type hudText struct {
+	text string
+}
+
+func (h *hudText) update(g *game.Game, cid int64) {
+	text := matchTimer(&g.Match)
+	for _, s := range g.Scoreboard.Scores {
+		if s.Cid == cid {
+			text += fmt.Sprintf("Kills %d  Deaths %d\n", s.Kills, s.Deaths)
+		}
+	}
+	if i, ok := g.E.Get(g.ControlledShip); ok {
+		if health := game.HealthKey.Get(i); health != nil {
+			text += fmt.Sprintf("Hull %.0f", *health)
+		}
+		if shield := game.ShieldKey.Get(i); shield != nil {
+			text += fmt.Sprintf("  Shield %.0f", shield.Value)
+		}
+		if w := game.WeaponKey.Get(i); w != nil {
+			text += fmt.Sprintf("\n%s %d", weaponNames[w.Kind], w.Ammo[w.Kind])
+		}
+	}
+
+	// Only touch the page when the text changes.
+	if text == h.text {
+		return
+	}
+	h.text = text
+	element := js.Global().Get("document").Call("getElementById", "hud")
+	element.Set("innerText", text)
+	element.Set("hidden", text == "")
+}
+
+var weaponNames = map[pb.WeaponKind]string{
+	pb.WeaponKind_MISSILE: "Missiles",
+	pb.WeaponKind_LASER:   "Laser",
+	pb.WeaponKind_MINE:    "Mines",
+	pb.WeaponKind_HOMING:  "Homing missiles",
+}
+
+func matchTimer(m *game.Match) string {
+	seconds := int(math.Ceil(float64(m.TimeLeft)))
+	switch m.Phase {
+	case pb.MatchState_COUNTDOWN:
+		return fmt.Sprintf("Starting in %d\n", seconds)
+	case pb.MatchState_LIVE:
+		if seconds > 0 {
+			return fmt.Sprintf("%d:%02d\n", seconds/60, seconds%60)
+		}
+	case pb.MatchState_SUDDEN_DEATH:
+		return "Sudden death\n"
+	}
+	return ""
+}
+
+// results shows the final scoreboard once the match has ended.
+var results = &resultsText{}
+
+type resultsText struct {
+	text string
+}
+
+func (r *resultsText) update(g *game.Game, cid int64) {
+	scores := append([]*pb.Score(nil), g.Scoreboard.Scores...)
+	sort.SliceStable(scores, func(a, b int) bool {
+		return game.Points(scores[a]) > game.Points(scores[b])
+	})
+
+	text := ""
+	teams := game.TeamPoints(g.Scoreboard)
+	if _, ok := teams[0]; len(teams) > 0 && !ok {
+		text += fmt.Sprintf("Team 1: %d  Team 2: %d\n\n", teams[1], teams[2])
+	}
+	for n, s := range scores {
+		name := fmt.Sprintf("Player %d", s.Cid)
+		if s.Cid == cid {
+			name = "You"
+		} else if game.IsBot(s.Cid) {
+			name = fmt.Sprintf("Bot %d", -s.Cid)
+		}
+		if s.Team != 0 {
+			name += fmt.Sprintf(" (%d)", s.Team)
+		}
+		if s.Departed {
+			name += " left"
+		}
+		text += fmt.Sprintf("%d. %-14s %3d kills %3d deaths\n", n+1, name, s.Kills, s.Deaths)
+	}
+
+	if text == r.text {
+		return
+	}
+	r.text = text
+	js.Global().Get("document").Call("getElementById", "results-text").Set("innerText", text)
+}
+
+// debugOverlay shows the game's profiler, toggled with F3.
+var debugOverlay = &debugText{}
+
+type debugText struct {
+	shown      bool
+	lastUpdate float64
+}
+
+func (d *debugText) element() js.Value {
+	return js.Global().Get("document").Call("getElementById", "debug-overlay")
+}
+
+func (d *debugText) toggle() {
+	d.shown = !d.shown
+	d.element().Set("hidden", !d.shown)
+}
+
+// update refreshes the text a few times a second, so that it can be read.
+func (d *debugText) update(now float64, p *game.Profiler) {
+	if !d.shown || now-d.lastUpdate < 250 {
+		return
+	}
+	d.lastUpdate = now
+	d.element().Set("innerText", p.String())
+}
+
 func fatalError(err error) {
 	setOverlay("overlay-error")
 	err = fmt.Errorf("An error has occured, refresh to continue:\n %w", err)
@@ -569,7 +723,7 @@ func combineToSend(c chan []*pb.Memo, memos []*pb.Memo) {
 	}
 }
 
-func isMemoRecipient(cid int64, memo *pb.Memo) bool {
+func isMemoRecipient(cid int64, team int32, memo *pb.Memo) bool {
 	switch r := memo.Recipient.(type) {
 	case *pb.Memo_To:
 		return cid == r.To
@@ -577,6 +731,8 @@ func isMemoRecipient(cid int64, memo *pb.Memo) bool {
 		return cid != r.EveryoneBut
 	case *pb.Memo_Everyone:
 		return true
+	case *pb.Memo_Team:
+		return team != 0 && team == r.Team
 	}
 	panic("Unknown recipient type")
 }
@@ -243,6 +243,10 @@ var spritemap = map[game.Sprite]*Sprite{
 		textureCoords: genTexCoords(512, 0, 1024, 512),
 		size:          1,
 	},
+	game.SpriteMine: &Sprite{
+		textureCoords: genTexCoords(0, 512, 512, 1024),
+		size:          0.4,
+	},
 }
 
 func genTexCoords(xStart, yStart, xEnd, yEnd float32) []float32 {
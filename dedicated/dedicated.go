@@ -21,6 +21,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -35,9 +36,11 @@ import (
 func main() {
 	log.Println("Initializing dedicated server")
 
-	playerConnected, playerDisconnected := startAgones()
+	// Allocation may choose the game mode, see startAgones.
+	modes := make(chan string, 1)
+	playerConnected, playerDisconnected := startAgones(modes)
 
-	http.Handle("/connect/", newDedicated(playerConnected, playerDisconnected))
+	http.Handle("/connect/", newDedicated(playerConnected, playerDisconnected, modes))
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, "Hello, %q", html.EscapeString(r.URL.Path))
@@ -58,14 +61,35 @@ type dedicated struct {
 	playerDisconnected func()
 }
 
-func newDedicated(playerConnected func(), playerDisconnected func()) websocket.Handler {
+func newDedicated(playerConnected func(), playerDisconnected func(), modes <-chan string) websocket.Handler {
+	seed := time.Now().UnixNano()
+	log.Println("Game seed", seed)
+
+	authoritative := serverAuthoritative()
+
 	d := &dedicated{
-		g:                  game.NewGame(),
+		g:                  game.NewDeterministicGame(seed),
 		nextCid:            make(chan int64, 1),
-		mr:                 newMemoRouter(),
+		mr:                 newMemoRouter(authoritative),
 		playerConnected:    playerConnected,
 		playerDisconnected: playerDisconnected,
 	}
+	d.g.ServerAuthoritative = authoritative
+	if ticks := rollbackTicks(); ticks > 0 {
+		if !authoritative {
+			log.Fatal("ROLLBACK_TICKS needs SERVER_AUTHORITATIVE")
+		}
+		d.g.EnableRollback(ticks)
+	}
+	d.g.Rules = matchRules()
+	if name, ok := os.LookupEnv("GAME_MODE"); ok {
+		mode, ok := game.LookupGameMode(name)
+		if !ok {
+			log.Fatal("Unknown GAME_MODE value:", name)
+		}
+		d.g.Mode = mode
+	}
+	log.Println("Game mode", d.g.Mode.Name())
 	inp := game.NewInput()
 	inp.IsRendered = false
 	inp.IsPlayer = false
@@ -73,20 +97,65 @@ func newDedicated(playerConnected func(), playerDisconnected func()) websocket.H
 
 	d.nextCid <- 1
 
+	// Bots are registered by the server sending itself their RegisterPlayer
+	// memos, so that recordings include them.
+	count := bots()
+	for n := 1; n <= count; n++ {
+		inp.SendTo(0, &pb.RegisterPlayer{
+			Cid: game.BotCid(n),
+		})
+	}
+
+	recorder := startRecording(d.g)
+
 	go func() {
 		toSend, receive := d.mr.connect(0)
 
+		const tick = time.Second / 60
+		// Overruns are logged at most once a second, so that a slow server doesn't
+		// also spend its time logging.
+		lastOverrunLog := time.Time{}
+		ended := false
+
 		last := time.Now()
-		for t := range time.Tick(time.Second / 60) {
+		for t := range time.Tick(tick) {
 			select {
 			case inp.Memos = <-toSend:
 			default:
 				inp.Memos = nil
 			}
 
+			select {
+			case name := <-modes:
+				setMode(d.g, name)
+			default:
+			}
+
 			inp.Dt = float32(t.Sub(last).Seconds())
 			last = t
+
+			if recorder != nil {
+				err := recorder.Record(inp)
+				if err != nil {
+					log.Println("Stopping recording, error:", err)
+					recorder = nil
+				}
+			}
+
+			// Time the whole step, which may run any number of ticks.
+			stepStart := time.Now()
 			d.g.Step(inp)
+			stepTime := time.Since(stepStart)
+
+			if stepTime > tick && time.Since(lastOverrunLog) > time.Second {
+				lastOverrunLog = time.Now()
+				log.Printf("Tick overrun, step took %v:\n%s", stepTime, d.g.Profiler)
+			}
+
+			if d.g.Match.Ended() && !ended {
+				ended = true
+				log.Println("Match ended:", d.g.Scoreboard)
+			}
 
 			receive(inp.MemosOut)
 			inp.MemosOut = nil
@@ -114,7 +183,10 @@ func (d *dedicated) Handler(c *websocket.Conn) {
 
 	go func() {
 		defer cancel()
-		err := stream.Send(&pb.ClientInitialize{Cid: cid})
+		err := stream.Send(&pb.ClientInitialize{
+			Cid:                 cid,
+			ServerAuthoritative: d.g.ServerAuthoritative,
+		})
 		if err != nil {
 			log.Printf("Client %d had send clientInitialize error %v", cid, err)
 			return
@@ -169,14 +241,29 @@ type memoRouter struct {
 	outgoing     map[int64]chan []*pb.Memo
 	outgoingLock sync.Mutex
 	createMemos  map[uint64]*pb.Memo
+	// scoreboard is the latest scoreboard memo, sent to players as they join.
+	scoreboard *pb.Memo
+	// matchState is the latest match state, and matchStateAt when it was sent,
+	// also sent as players join.
+	matchState   *pb.MatchState
+	matchStateAt time.Time
+	// teams are the teams of the players' ships, for memos sent to a team.
+	teams map[int64]int32
+
+	// serverAuthoritative drops every memo from clients except the few they
+	// need to play, see allowedFromClient.
+	serverAuthoritative bool
 }
 
-func newMemoRouter() *memoRouter {
+func newMemoRouter(serverAuthoritative bool) *memoRouter {
 	mr := &memoRouter{
 		incoming: make(chan []*pb.Memo, 1),
 		outgoing: make(map[int64]chan []*pb.Memo),
 
 		createMemos: make(map[uint64]*pb.Memo),
+		teams:       make(map[int64]int32),
+
+		serverAuthoritative: serverAuthoritative,
 	}
 
 	go func() {
@@ -193,16 +280,25 @@ func newMemoRouter() *memoRouter {
 				case *pb.Memo_SpawnMissile:
 					actual := a.SpawnMissile
 					mr.createMemos[actual.Nid] = memo
+				case *pb.Memo_SpawnMine:
+					actual := a.SpawnMine
+					mr.createMemos[actual.Nid] = memo
 				case *pb.Memo_SpawnShip:
 					actual := a.SpawnShip
 					mr.createMemos[actual.Nid] = memo
+					mr.teams[actual.Authority] = actual.Team
 				case *pb.Memo_DestroyEvent:
 					actual := a.DestroyEvent
 					delete(mr.createMemos, actual.Nid)
+				case *pb.Memo_Scoreboard:
+					mr.scoreboard = memo
+				case *pb.Memo_MatchState:
+					mr.matchState = a.MatchState
+					mr.matchStateAt = time.Now()
 				}
 
 				for cid := range mr.outgoing {
-					if isMemoRecipient(cid, memo) {
+					if isMemoRecipient(cid, mr.teams[cid], memo) {
 						pending[cid] = append(pending[cid], memo)
 					}
 				}
@@ -238,24 +334,88 @@ func (mr *memoRouter) connect(cid int64) (toSend chan []*pb.Memo, recieve func([
 	for _, memo := range mr.createMemos {
 		memos = append(memos, memo)
 	}
+	if mr.scoreboard != nil {
+		memos = append(memos, mr.scoreboard)
+	}
+	if mr.matchState != nil {
+		// The time left has run down since the host sent it.
+		timeLeft := mr.matchState.TimeLeft - float32(time.Since(mr.matchStateAt).Seconds())
+		if timeLeft < 0 {
+			timeLeft = 0
+		}
+		memos = append(memos, &pb.Memo{
+			Recipient: &pb.Memo_To{To: cid},
+			Actual: &pb.Memo_MatchState{
+				MatchState: &pb.MatchState{
+					Phase:    mr.matchState.Phase,
+					TimeLeft: timeLeft,
+				},
+			},
+		})
+	}
 	toSend <- memos
 
 	recieve = func(memos []*pb.Memo) {
+		if mr.serverAuthoritative && cid != 0 {
+			memos = mr.filterFromClient(cid, memos)
+		}
 		combineToSend(mr.incoming, memos)
 	}
 
 	return toSend, recieve
 }
 
-func (mr *memoRouter) disconnect(cid int64) {
-	// TODO: send disconnect memo
+func (mr *memoRouter) filterFromClient(cid int64, memos []*pb.Memo) []*pb.Memo {
 	mr.outgoingLock.Lock()
 	defer mr.outgoingLock.Unlock()
 
+	allowed := make([]*pb.Memo, 0, len(memos))
+	for _, memo := range memos {
+		if mr.allowedFromClient(cid, memo) {
+			allowed = append(allowed, memo)
+		}
+	}
+	if dropped := len(memos) - len(allowed); dropped > 0 {
+		log.Printf("Client %d sent %d memos it isn't allowed to", cid, dropped)
+	}
+	return allowed
+}
+
+// allowedFromClient is whether a client in a server authoritative game may
+// send the memo.  Clients may only ask the server to spawn them, and send it
+// the controls for their own ship.
+func (mr *memoRouter) allowedFromClient(cid int64, memo *pb.Memo) bool {
+	if to, ok := memo.Recipient.(*pb.Memo_To); !ok || to.To != 0 {
+		return false
+	}
+
+	switch a := memo.Actual.(type) {
+	case *pb.Memo_RegisterPlayer:
+		return a.RegisterPlayer.Cid == cid
+	case *pb.Memo_ShipControlTrack:
+		spawn, ok := mr.createMemos[a.ShipControlTrack.Nid].GetActual().(*pb.Memo_SpawnShip)
+		return ok && spawn.SpawnShip.Authority == cid
+	}
+	return false
+}
+
+func (mr *memoRouter) disconnect(cid int64) {
+	mr.outgoingLock.Lock()
 	delete(mr.outgoing, cid)
+	delete(mr.teams, cid)
+	mr.outgoingLock.Unlock()
+
+	combineToSend(mr.incoming, []*pb.Memo{
+		{
+			Recipient: &pb.Memo_To{To: 0},
+			Actual: &pb.Memo_PlayerLeft{
+				PlayerLeft: &pb.PlayerLeft{Cid: cid},
+			},
+		},
+	})
 }
 
-func isMemoRecipient(cid int64, memo *pb.Memo) bool {
+func isMemoRecipient(cid int64, team int32, memo *pb.Memo) bool {
 	switch r := memo.Recipient.(type) {
 	case *pb.Memo_To:
 		return cid == r.To
@@ -263,6 +423,8 @@ func isMemoRecipient(cid int64, memo *pb.Memo) bool {
 		return cid != r.EveryoneBut
 	case *pb.Memo_Everyone:
 		return true
+	case *pb.Memo_Team:
+		return team != 0 && team == r.Team
 	}
 	panic("Unknown recipient type")
 }
@@ -271,7 +433,156 @@ func isMemoRecipient(cid int64, memo *pb.Memo) bool {
 ///////////////////////////////////////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////
 
-func startAgones() (playerConnected func(), playerDisconnected func()) {
+// startRecording records the match to the file named by RECORD_FILE, if it is
+// set.  Run the file with the replay command to reproduce the match.
+func startRecording(g *game.Game) *game.Recorder {
+	path, ok := os.LookupEnv("RECORD_FILE")
+	if !ok {
+		return nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal("Unable to create recording: ", err)
+	}
+	r, err := game.NewRecorder(f, g)
+	if err != nil {
+		log.Fatal("Unable to start recording: ", err)
+	}
+
+	log.Println("Recording match to", path)
+	return r
+}
+
+// serverAuthoritative is whether SERVER_AUTHORITATIVE is set to true, in which
+// case the server simulates every ship instead of trusting the clients.
+func serverAuthoritative() bool {
+	v, ok := os.LookupEnv("SERVER_AUTHORITATIVE")
+	if !ok {
+		return false
+	}
+	switch v {
+	case "true":
+		log.Println("Server authoritative")
+		return true
+	case "false":
+		return false
+	}
+	log.Fatal("Unknown SERVER_AUTHORITATIVE value:", v)
+	return false
+}
+
+// rollbackTicks is how many ticks the server may roll back for late controls,
+// from ROLLBACK_TICKS.  0 disables rollback.
+func rollbackTicks() int {
+	v, ok := os.LookupEnv("ROLLBACK_TICKS")
+	if !ok {
+		return 0
+	}
+	ticks, err := strconv.Atoi(v)
+	if err != nil || ticks < 0 {
+		log.Fatal("Unknown ROLLBACK_TICKS value:", v)
+	}
+	if ticks > 0 {
+		log.Println("Rollback enabled for", ticks, "ticks")
+	}
+	return ticks
+}
+
+// bots is how many bots play, from BOTS.
+func bots() int {
+	v, ok := os.LookupEnv("BOTS")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Fatal("Unknown BOTS value:", v)
+	}
+	if n > 0 {
+		log.Println("Adding", n, "bots")
+	}
+	return n
+}
+
+// setMode changes the game mode to the one named, as long as the match hasn't
+// started yet.
+func setMode(g *game.Game, name string) {
+	mode, ok := game.LookupGameMode(name)
+	if !ok {
+		log.Println("Ignoring unknown game mode", name)
+		return
+	}
+	if g.Match.Phase != pb.MatchState_WAITING_FOR_PLAYERS {
+		log.Println("Ignoring game mode", name, "as the match has started")
+		return
+	}
+	log.Println("Game mode", name)
+	g.Mode = mode
+}
+
+// matchRules are the default match rules, with any of them overridden by
+// MATCH_MIN_PLAYERS, MATCH_COUNTDOWN, MATCH_TIME_LIMIT, MATCH_KILL_TARGET and
+// MATCH_FRIENDLY_FIRE.  Times are in seconds, and 0 disables a rule.
+func matchRules() *pb.MatchRules {
+	rules := game.DefaultMatchRules()
+	lookupInt("MATCH_MIN_PLAYERS", &rules.MinPlayers)
+	lookupFloat("MATCH_COUNTDOWN", &rules.Countdown)
+	lookupFloat("MATCH_TIME_LIMIT", &rules.TimeLimit)
+	lookupInt("MATCH_KILL_TARGET", &rules.KillTarget)
+	lookupBool("MATCH_FRIENDLY_FIRE", &rules.FriendlyFire)
+	log.Println("Match rules:", rules)
+	return rules
+}
+
+func lookupInt(name string, v *int32) {
+	s, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	i, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || i < 0 {
+		log.Fatal("Unknown ", name, " value:", s)
+	}
+	*v = int32(i)
+}
+
+func lookupBool(name string, v *bool) {
+	s, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	switch s {
+	case "true":
+		*v = true
+	case "false":
+		*v = false
+	default:
+		log.Fatal("Unknown ", name, " value:", s)
+	}
+}
+
+func lookupFloat(name string, v *float32) {
+	s, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil || f < 0 {
+		log.Fatal("Unknown ", name, " value:", s)
+	}
+	*v = float32(f)
+}
+
+///////////////////////////////////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////
+
+// gameModeAnnotation is the allocation metadata which chooses the game mode.
+const gameModeAnnotation = "space-agon/game-mode"
+
+// startAgones sends the game mode to modes if the allocation chooses one.
+func startAgones(modes chan<- string) (playerConnected func(), playerDisconnected func()) {
 	waitForEmpty := &sync.WaitGroup{}
 
 	{
@@ -305,6 +616,9 @@ func startAgones() (playerConnected func(), playerDisconnected func()) {
 		if gs.GetStatus().GetState() == "Allocated" {
 			shutdown.Do(func() {
 				log.Println("Detected the server is allocated.")
+				if mode, ok := gs.GetObjectMeta().GetAnnotations()[gameModeAnnotation]; ok {
+					modes <- mode
+				}
 				select {
 				case <-time.After(time.Minute * 15):
 					log.Println("Done waiting for first player to join.")
@@ -0,0 +1,216 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"math"
+
+	"github.com/laremere/space-agon/game/pb"
+)
+
+// Bots are players flown by the host.  They have negative cids, so they never
+// clash with a connected player, and join by the host sending itself a
+// RegisterPlayer for one.  The host then keeps a BotPilot entity for the bot,
+// which sets the controls of its ship every tick, and registers the bot again
+// when its ship is destroyed.  Their ships are otherwise like any other, so
+// they are sent to clients and scored the same way.
+//
+// A bot keeps its ship in a circular orbit, and climbs away from the sun if it
+// gets too close.  While its orbit is good it turns to lead the nearest enemy
+// in range, and fires once it's aimed.
+
+const (
+	botRespawnDelay = 4
+
+	// botOrbit is the radius bots orbit at.  Below botDanger they do nothing
+	// but climb back up.
+	botOrbit  = 14
+	botDanger = 7
+	// botDrift is how far a bot's momentum can be from its orbit's before it
+	// stops fighting to correct it.
+	botDrift = 1.5
+	// botRange is how far away bots shoot at ships, a little short of how far
+	// a missile flies.
+	botRange = 20
+	// botAim is how close, in radians, a bot must be aimed to fire or thrust.
+	botAim = 0.1
+)
+
+// IsBot returns whether the cid belongs to a bot.
+func IsBot(cid int64) bool {
+	return cid < 0
+}
+
+// BotCid returns the cid of the nth bot, counting from 1.
+func BotCid(n int) int64 {
+	return -int64(n)
+}
+
+// addBot creates a pilot for the bot, if it doesn't have one yet.
+func (g *Game) addBot(cid int64) {
+	if _, ok := g.pilot(cid); ok {
+		return
+	}
+	i := g.E.NewIter()
+	i.Require(BotPilotKey)
+	i.New()
+	BotPilotKey.Get(i).Cid = cid
+}
+
+func (g *Game) pilot(cid int64) (*BotPilot, bool) {
+	i := g.E.NewIter()
+	i.Require(BotPilotKey)
+	for i.Next() {
+		if p := BotPilotKey.Get(i); p.Cid == cid {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (g *Game) botSpawned(cid int64, ship EntityID) {
+	if p, ok := g.pilot(cid); ok {
+		p.Ship = ship
+		p.TimeDead = 0
+	}
+}
+
+func (g *Game) flyBots(input *Input) {
+	pilots := g.E.NewIter()
+	pilots.Require(BotPilotKey)
+	ship := g.E.NewIter()
+
+	for pilots.Next() {
+		p := BotPilotKey.Get(pilots)
+		if !ship.Get(p.Ship) {
+			p.TimeDead += input.Dt
+			if p.TimeDead > botRespawnDelay {
+				p.TimeDead = 0
+				input.SendTo(0, &pb.RegisterPlayer{
+					Cid: p.Cid,
+				})
+			}
+			continue
+		}
+
+		g.flyBot(ship, p.Cid)
+	}
+}
+
+// flyBot sets the controls of the bot's ship the iter is pointing at.
+func (g *Game) flyBot(i *Iter, cid int64) {
+	pos := *PosKey.Get(i)
+	momentum := *MomentumKey.Get(i)
+	sc := ShipControlKey.Get(i)
+	sc.Up = false
+	sc.Left = false
+	sc.Right = false
+	sc.Fire = false
+
+	// The momentum of a circular orbit, in whichever direction the ship is
+	// already going, nudged back towards the orbit's radius.
+	r := pos.Length()
+	out := pos.Scale(1 / r)
+	along := Vec2{-out[1], out[0]}
+	if along.Dot(momentum) < 0 {
+		along = along.Scale(-1)
+	}
+	speed := float32(math.Sqrt(gravityStrength / float64(r)))
+	want := along.Scale(speed).Add(out.Scale((botOrbit - r) * 0.3))
+	correction := want.Sub(momentum)
+
+	if r < botDanger || correction.Length() > botDrift {
+		sc.Up = turnTowards(i, radians(correction))
+		return
+	}
+
+	targetPos, targetMomentum, ok := g.botTarget(i, cid)
+	if !ok {
+		sc.Up = turnTowards(i, radians(correction)) && correction.Length() > botDrift/3
+		return
+	}
+
+	aim := lead(targetPos.Sub(pos), momentum, targetMomentum)
+	sc.Fire = turnTowards(i, radians(aim)) && sc.FireCoolDown <= 0
+}
+
+// botTarget returns the position and momentum of the nearest enemy ship in
+// range of the bot's ship the iter is pointing at.
+func (g *Game) botTarget(i *Iter, cid int64) (pos Vec2, momentum Vec2, ok bool) {
+	team := *TeamKey.Get(i)
+	closest := float32(botRange)
+
+	other := g.E.NewIter()
+	other.Require(PosKey)
+	other.Require(MomentumKey)
+	other.Require(ShipControlKey)
+	other.Require(AuthorityKey)
+	other.Require(TeamKey)
+	for other.Next() {
+		if *AuthorityKey.Get(other) == cid || g.harmless(team, *TeamKey.Get(other)) {
+			continue
+		}
+		diff := PosKey.Get(other).Sub(*PosKey.Get(i))
+		if dist := diff.Length(); dist < closest {
+			closest = dist
+			pos = *PosKey.Get(other)
+			momentum = *MomentumKey.Get(other)
+			ok = true
+		}
+	}
+	return pos, momentum, ok
+}
+
+// lead returns the direction to fire a missile to hit a target at diff which
+// keeps its momentum, ignoring gravity.
+func lead(diff Vec2, shooter Vec2, target Vec2) Vec2 {
+	v := target.Sub(shooter)
+
+	// Solve |diff + v*t| = MissileSpeed*t for the earliest time t after now.
+	a := v.Dot(v) - MissileSpeed*MissileSpeed
+	b := 2 * diff.Dot(v)
+	c := diff.Dot(diff)
+	d := b*b - 4*a*c
+	if d < 0 || a == 0 {
+		return diff
+	}
+	sqrt := float32(math.Sqrt(float64(d)))
+	t := (-b - sqrt) / (2 * a)
+	if t2 := (-b + sqrt) / (2 * a); t <= 0 || (t2 > 0 && t2 < t) {
+		t = t2
+	}
+	if t <= 0 {
+		return diff
+	}
+	return diff.Add(v.Scale(t))
+}
+
+// turnTowards sets the ship the iter is pointing at turning towards the angle,
+// and returns whether it's already pointing there.
+func turnTowards(i *Iter, angle float32) bool {
+	sc := ShipControlKey.Get(i)
+	diff := float32(math.Remainder(float64(angle-*RotKey.Get(i)), 2*math.Pi))
+
+	// Aim for where the ship will be pointing once its spin has been stopped.
+	turn := diff - *SpinKey.Get(i)*0.3
+	sc.Left = turn > botAim/2
+	sc.Right = turn < -botAim/2
+
+	return diff < botAim && diff > -botAim
+}
+
+func radians(v Vec2) float32 {
+	return float32(math.Atan2(float64(v[1]), float64(v[0])))
+}
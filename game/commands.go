@@ -0,0 +1,91 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+// Commands is a buffer of spawns, removals and component changes.  Removing
+// or creating entities while an Iter is walking over them is easy to get
+// wrong, so systems can queue the change here instead and it will happen the
+// next time Apply is called.
+type Commands struct {
+	e       *Entities
+	pending []func()
+}
+
+// Spawn queues the creation of an entity with the given components.  If init
+// isn't nil, it is called with an Iter pointing at the new entity so that its
+// components can be set.
+func (c *Commands) Spawn(init func(i *Iter), keys ...CompKey) {
+	c.pending = append(c.pending, func() {
+		i := c.e.NewIter()
+		for _, k := range keys {
+			i.Require(k)
+		}
+		i.New()
+		if init != nil {
+			init(i)
+		}
+	})
+}
+
+// Remove queues the removal of the entity.  Removing an entity which has
+// already been removed by the time the commands are applied does nothing.
+func (c *Commands) Remove(id EntityID) {
+	c.pending = append(c.pending, func() {
+		i, ok := c.e.Get(id)
+		if !ok {
+			return
+		}
+		i.Remove()
+	})
+}
+
+// AddComponent queues giving the entity the component k.  If init isn't nil,
+// it is called with an Iter pointing at the entity once it has the component.
+func (c *Commands) AddComponent(id EntityID, k CompKey, init func(i *Iter)) {
+	c.pending = append(c.pending, func() {
+		i, ok := c.e.Get(id)
+		if !ok {
+			return
+		}
+		i.AddComponent(k)
+		if init != nil {
+			init(i)
+		}
+	})
+}
+
+// RemoveComponent queues taking the component k from the entity.
+func (c *Commands) RemoveComponent(id EntityID, k CompKey) {
+	c.pending = append(c.pending, func() {
+		i, ok := c.e.Get(id)
+		if !ok {
+			return
+		}
+		i.RemoveComponent(k)
+	})
+}
+
+// Apply carries out all queued commands, in the order they were queued.  It
+// must not be called while an Iter is walking over the entities.
+func (c *Commands) Apply() {
+	// Commands run by Apply may queue further commands, these are also applied.
+	for len(c.pending) > 0 {
+		pending := c.pending
+		c.pending = nil
+		for _, command := range pending {
+			command()
+		}
+	}
+}
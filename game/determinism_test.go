@@ -0,0 +1,82 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/laremere/space-agon/game/pb"
+)
+
+// TestDeterminism runs two hosts with the same seed and bots, and checks that
+// they end up with identical snapshots.
+func TestDeterminism(t *testing.T) {
+	const seed = 42
+	const bots = 3
+	const ticks = 60 * 20
+
+	run := func() []byte {
+		g := NewDeterministicGame(seed)
+		input := NewInput()
+		input.IsHost = true
+		for n := 1; n <= bots; n++ {
+			input.SendTo(0, &pb.RegisterPlayer{
+				Cid: BotCid(n),
+			})
+		}
+
+		for tick := 0; tick < ticks; tick++ {
+			input.Memos = toHost(input.MemosOut)
+			input.MemosOut = nil
+			input.Dt = TickDt
+			g.Step(input)
+		}
+
+		snapshot, err := g.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return snapshot
+	}
+
+	a := run()
+	b := run()
+	if !bytes.Equal(a, b) {
+		t.Errorf("Games with the same seed differ: snapshots of %d and %d bytes", len(a), len(b))
+	}
+}
+
+// toHost returns the memos which the host gets back of those it sent, as it
+// would from the dedicated server's memo router.
+func toHost(memos []*pb.Memo) []*pb.Memo {
+	var back []*pb.Memo
+	for _, memo := range memos {
+		switch r := memo.Recipient.(type) {
+		case *pb.Memo_To:
+			if r.To != 0 {
+				continue
+			}
+		case *pb.Memo_EveryoneBut:
+			if r.EveryoneBut == 0 {
+				continue
+			}
+		case *pb.Memo_Team:
+			continue
+		}
+		back = append(back, memo)
+	}
+	return back
+}
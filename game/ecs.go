@@ -0,0 +1,601 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Component registration
+////////////////////////////////////////////////////////////////////////////////
+
+// CompKey identifies a kind of component.  It is implemented by *Component[T],
+// *Tag and *LookupComponent, which are created by registering a component.
+type CompKey interface {
+	compID() compID
+}
+
+type compID uint16
+
+const maxComponents = 128
+
+type componentInfo struct {
+	name string
+	// newColumn is nil for components which hold no data.
+	newColumn func(e *Entities) Comp
+}
+
+var registered []componentInfo
+
+func register(name string, newColumn func(e *Entities) Comp) compID {
+	if len(registered) == maxComponents {
+		panic("Too many components registered, increase maxComponents")
+	}
+	for _, info := range registered {
+		if info.name == name {
+			panic("Component registered twice: " + name)
+		}
+	}
+	registered = append(registered, componentInfo{
+		name:      name,
+		newColumn: newColumn,
+	})
+	return compID(len(registered) - 1)
+}
+
+// Component is the key for a component which stores a T for every entity
+// which has it.  Components are usually declared as package level variables,
+// and may be declared in any package:
+//
+//	var HealthKey = game.NewComponent[float32]("Health")
+type Component[T any] struct {
+	id compID
+}
+
+// NewComponent registers a component holding values of type T.  The name must
+// be unique, and T must be fixed size so that it can be saved in snapshots.
+func NewComponent[T any](name string) *Component[T] {
+	var zero T
+	if binary.Size(zero) < 0 {
+		panic("Component type isn't fixed size: " + name)
+	}
+	return &Component[T]{
+		id: register(name, func(*Entities) Comp { return &Column[T]{} }),
+	}
+}
+
+func (c *Component[T]) compID() compID {
+	return c.id
+}
+
+// Get returns the component of the entity the iter is pointing at, or nil if
+// the entity doesn't have it.
+func (c *Component[T]) Get(iter *Iter) *T {
+	comp := iter.e.bags[iter.i].cols[c.id]
+	if comp == nil {
+		return nil
+	}
+	return &comp.(*Column[T]).data[iter.j]
+}
+
+// Tag is the key for a component which holds no data, it is only used to
+// select which entities an Iter visits.
+type Tag struct {
+	id compID
+}
+
+// NewTag registers a component which holds no data.  The name must be unique.
+func NewTag(name string) *Tag {
+	return &Tag{
+		id: register(name, nil),
+	}
+}
+
+func (t *Tag) compID() compID {
+	return t.id
+}
+
+// LookupComponent is the key for the one component which gives entities an
+// EntityID.
+type LookupComponent struct {
+	id compID
+}
+
+var LookupKey = &LookupComponent{
+	id: register("Lookup", func(e *Entities) Comp { return &lookupColumn{e: e} }),
+}
+
+func (l *LookupComponent) compID() compID {
+	return l.id
+}
+
+// Get returns the id of the entity the iter is pointing at, or the zero
+// EntityID if the entity doesn't have a Lookup.
+func (l *LookupComponent) Get(iter *Iter) EntityID {
+	comp := iter.e.bags[iter.i].cols[l.id]
+	if comp == nil {
+		return 0
+	}
+	return comp.(*lookupColumn).ids[iter.j]
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Component storage
+////////////////////////////////////////////////////////////////////////////////
+
+type Comp interface {
+	Swap(j1, j2 int)
+	Extend(i int)
+	RemoveLast()
+	// moveFrom sets entity dj of this to entity j of src, which must be the
+	// same type.
+	moveFrom(src Comp, j int, dj int)
+	// write and read save and load the data for all entities, see snapshot.go.
+	write(w io.Writer) error
+	read(r *bytes.Reader, i int, count int) error
+}
+
+// Column stores one component for every entity in a bag.
+type Column[T any] struct {
+	data []T
+}
+
+func (c *Column[T]) Swap(j1, j2 int) {
+	c.data[j1], c.data[j2] = c.data[j2], c.data[j1]
+}
+
+func (c *Column[T]) Extend(i int) {
+	var zero T
+	c.data = append(c.data, zero)
+}
+
+func (c *Column[T]) RemoveLast() {
+	c.data = c.data[:len(c.data)-1]
+}
+
+func (c *Column[T]) moveFrom(src Comp, j int, dj int) {
+	c.data[dj] = src.(*Column[T]).data[j]
+}
+
+// Lookup has a special implementation which keeps the entity's slot in
+// Entities up to date as it is moved.
+type lookupColumn struct {
+	e   *Entities
+	ids []EntityID
+}
+
+func (c *lookupColumn) Swap(j1, j2 int) {
+	c.ids[j1], c.ids[j2] = c.ids[j2], c.ids[j1]
+	c.e.slots[c.ids[j1].index()].j = j1
+	c.e.slots[c.ids[j2].index()].j = j2
+}
+
+func (c *lookupColumn) Extend(i int) {
+	c.ids = append(c.ids, c.e.newID(i, len(c.ids)))
+}
+
+func (c *lookupColumn) RemoveLast() {
+	j := len(c.ids) - 1
+	c.e.freeID(c.ids[j])
+	c.ids = c.ids[:j]
+}
+
+// The ids are swapped rather than copied, so the entity keeps its EntityID
+// while the one created for dj is left behind in src to be freed when src's
+// entity is removed.
+func (c *lookupColumn) moveFrom(src Comp, j int, dj int) {
+	s := src.(*lookupColumn)
+	moved := s.ids[j]
+	left := c.ids[dj]
+	s.ids[j], c.ids[dj] = left, moved
+
+	// The two entities have traded places, so they trade slot locations too.
+	movedSlot := &c.e.slots[moved.index()]
+	leftSlot := &c.e.slots[left.index()]
+	movedSlot.i, movedSlot.j, leftSlot.i, leftSlot.j = leftSlot.i, leftSlot.j, movedSlot.i, movedSlot.j
+}
+
+type EntityBag struct {
+	count    int
+	comps    []Comp
+	compsKey compsKey
+	// name lists the components in the bag, for debugging.
+	name string
+
+	// cols holds the column for each component, indexed by compID.  It is nil
+	// for components this bag doesn't have.
+	cols [maxComponents]Comp
+}
+
+func newEntityBag(e *Entities, compsKey *compsKey) *EntityBag {
+	bag := &EntityBag{
+		count:    0,
+		comps:    nil,
+		compsKey: *compsKey,
+	}
+
+	var names []string
+	for id, info := range registered {
+		if !inRequirement(compsKey, compID(id)) {
+			continue
+		}
+		names = append(names, info.name)
+		if info.newColumn != nil {
+			bag.cols[id] = info.newColumn(e)
+			bag.comps = append(bag.comps, bag.cols[id])
+		}
+	}
+	bag.name = strings.Join(names, ",")
+
+	return bag
+}
+
+// moveShared copies all components which both bags have from entity j in src
+// to entity dj in dst.
+func moveShared(src *EntityBag, j int, dst *EntityBag, dj int) {
+	for id, c := range dst.cols {
+		if c != nil && src.cols[id] != nil {
+			c.moveFrom(src.cols[id], j, dj)
+		}
+	}
+}
+
+type compsKey [maxComponents / compsKeyUnitSize]uint64
+
+const compsKeyUnitSize = 64
+
+func (c *compsKey) set(id compID) {
+	c[id/compsKeyUnitSize] |= 1 << (id % compsKeyUnitSize)
+}
+
+func (c *compsKey) clear(id compID) {
+	c[id/compsKeyUnitSize] &^= 1 << (id % compsKeyUnitSize)
+}
+
+func inRequirement(compsKey *compsKey, id compID) bool {
+	return 0 < (*compsKey)[id/compsKeyUnitSize]&(1<<(id%compsKeyUnitSize))
+}
+
+func (e *EntityBag) Add(i int) int {
+	j := e.count
+	e.count++
+	for _, c := range e.comps {
+		c.Extend(i)
+	}
+	return j
+}
+
+func (e *EntityBag) Remove(i int) {
+	e.count--
+	for _, c := range e.comps {
+		c.Swap(e.count, i)
+		c.RemoveLast()
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Iteration
+////////////////////////////////////////////////////////////////////////////////
+
+type Iter struct {
+	e *Entities
+	i int
+	j int
+	q query
+
+	// matched is the list of bags meeting q, looked up on the first call to Next.
+	// m is the position in that list of the bag i.
+	matched *matchedBags
+	m       int
+}
+
+// query is the set of filters an Iter uses to decide which bags to visit.  It
+// is comparable so that the bags matching it can be cached in Entities.
+type query struct {
+	requirements compsKey
+	exclusions   compsKey
+	anyOf        [maxAnySets]compsKey
+	anyCount     int
+}
+
+const maxAnySets = 4
+
+func (iter *Iter) Require(k CompKey) {
+	iter.q.requirements.set(k.compID())
+	iter.matched = nil
+}
+
+// Exclude skips entities which have the component k.
+func (iter *Iter) Exclude(k CompKey) {
+	iter.q.exclusions.set(k.compID())
+	iter.matched = nil
+}
+
+// Any skips entities which have none of the components in keys.  Each call
+// adds another set which must be matched.
+func (iter *Iter) Any(keys ...CompKey) {
+	if iter.q.anyCount == maxAnySets {
+		panic("Too many calls to Any on one Iter")
+	}
+	set := &iter.q.anyOf[iter.q.anyCount]
+	for _, k := range keys {
+		set.set(k.compID())
+	}
+	iter.q.anyCount++
+	iter.matched = nil
+}
+
+func (iter *Iter) Next() bool {
+	if iter.matched == nil {
+		iter.matched = iter.e.matching(&iter.q)
+		iter.m = -1
+	}
+
+	iter.j++
+	for iter.m == -1 || iter.j >= iter.e.bags[iter.i].count {
+		iter.m++
+		// Read the length each time, bags created during iteration are appended.
+		if iter.m >= len(iter.matched.bags) {
+			return false
+		}
+		iter.i = iter.matched.bags[iter.m]
+		iter.j = 0
+	}
+	return true
+}
+
+func (q *query) matches(bag *EntityBag) bool {
+	for i := 0; i < len(q.requirements); i++ {
+		if q.requirements[i] != (q.requirements[i] & bag.compsKey[i]) {
+			return false
+		}
+		if q.exclusions[i]&bag.compsKey[i] != 0 {
+			return false
+		}
+	}
+	for _, set := range q.anyOf[:q.anyCount] {
+		found := false
+		for i := 0; i < len(set); i++ {
+			if set[i]&bag.compsKey[i] != 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (iter *Iter) New() {
+	iter.i = iter.e.bagIndex(&iter.q.requirements)
+	iter.j = iter.e.bags[iter.i].Add(iter.i)
+}
+
+// AddComponent gives the entity the iter is pointing at the component k, by
+// moving it into the bag for its new set of components.  All existing component
+// data is kept, and the entity's Lookup stays valid.  Afterwards the iter points
+// at the entity's new location so that the added component can be set, which
+// means calling Next on this iter will no longer continue the original query.
+func (iter *Iter) AddComponent(k CompKey) {
+	key := iter.e.bags[iter.i].compsKey
+	if inRequirement(&key, k.compID()) {
+		return
+	}
+	key.set(k.compID())
+	iter.move(&key)
+}
+
+// RemoveComponent takes the component k from the entity the iter is pointing
+// at.  See AddComponent for how the iter is left afterwards.
+func (iter *Iter) RemoveComponent(k CompKey) {
+	key := iter.e.bags[iter.i].compsKey
+	if !inRequirement(&key, k.compID()) {
+		return
+	}
+	key.clear(k.compID())
+	iter.move(&key)
+}
+
+func (iter *Iter) move(compsKey *compsKey) {
+	src := iter.e.bags[iter.i]
+	i := iter.e.bagIndex(compsKey)
+	dst := iter.e.bags[i]
+	j := dst.Add(i)
+
+	moveShared(src, iter.j, dst, j)
+	src.Remove(iter.j)
+
+	iter.i = i
+	iter.j = j
+}
+
+// Get points the iter at the entity with the given id.  It returns false, and
+// leaves the iter unchanged, if that entity has been removed.
+func (iter *Iter) Get(id EntityID) bool {
+	slot, ok := iter.e.slot(id)
+	if !ok {
+		return false
+	}
+	iter.i = slot.i
+	iter.j = slot.j
+	return true
+}
+
+// Has returns whether the entity the iter is pointing at has the component k.
+func (iter *Iter) Has(k CompKey) bool {
+	return inRequirement(&iter.e.bags[iter.i].compsKey, k.compID())
+}
+
+func (iter *Iter) Remove() {
+	iter.e.bags[iter.i].Remove(iter.j)
+	// So that a call to next will arrive at this index, which now contains  a
+	// different entity.
+	iter.j--
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Entities
+////////////////////////////////////////////////////////////////////////////////
+
+// EntityID is a stable handle to an entity with a Lookup.  It stays the same
+// while the entity is moved around, and is never reused for another entity, so
+// holding on to one after the entity is removed is safe.  The zero EntityID is
+// never used.
+type EntityID uint64
+
+func (id EntityID) index() uint32 {
+	return uint32(id)
+}
+
+func (id EntityID) generation() uint32 {
+	return uint32(id >> 32)
+}
+
+// entitySlot records where the entity using the slot currently is.  The
+// generation is bumped every time the slot is freed, which invalidates all
+// ids handed out for it.
+type entitySlot struct {
+	generation uint32
+	i          int
+	j          int
+}
+
+type Entities struct {
+	bags      []*EntityBag
+	bagsByKey map[compsKey]int
+
+	slots     []entitySlot
+	freeSlots []uint32
+
+	// matched caches which bags meet each query that has been iterated over.  It
+	// is kept up to date as new bags are created.
+	matched map[query]*matchedBags
+
+	// Commands are changes to the entities which have been put off until it is
+	// safe to apply them.
+	Commands *Commands
+}
+
+// bagIndex returns the index of the bag holding exactly the components in
+// compsKey, creating it if needed.
+func (e *Entities) bagIndex(compsKey *compsKey) int {
+	i, ok := e.bagsByKey[*compsKey]
+	if !ok {
+		i = len(e.bags)
+		e.bagsByKey[*compsKey] = i
+		e.bags = append(e.bags, newEntityBag(e, compsKey))
+
+		for _, m := range e.matched {
+			if m.q.matches(e.bags[i]) {
+				m.bags = append(m.bags, i)
+			}
+		}
+	}
+	return i
+}
+
+func (e *Entities) newID(i, j int) EntityID {
+	var index uint32
+	if n := len(e.freeSlots); n > 0 {
+		index = e.freeSlots[n-1]
+		e.freeSlots = e.freeSlots[:n-1]
+	} else {
+		index = uint32(len(e.slots))
+		e.slots = append(e.slots, entitySlot{generation: 1})
+	}
+
+	slot := &e.slots[index]
+	slot.i = i
+	slot.j = j
+	return EntityID(uint64(slot.generation)<<32 | uint64(index))
+}
+
+func (e *Entities) freeID(id EntityID) {
+	slot := &e.slots[id.index()]
+	slot.generation++
+	if slot.generation == 0 {
+		// Skip 0 on wrap around so that the zero EntityID stays invalid.
+		slot.generation = 1
+	}
+	e.freeSlots = append(e.freeSlots, id.index())
+}
+
+func (e *Entities) slot(id EntityID) (*entitySlot, bool) {
+	if int(id.index()) >= len(e.slots) {
+		return nil, false
+	}
+	slot := &e.slots[id.index()]
+	if slot.generation != id.generation() {
+		return nil, false
+	}
+	return slot, true
+}
+
+// Get returns an Iter pointing at the entity with the given id.  ok is false
+// if that entity has been removed.
+func (e *Entities) Get(id EntityID) (iter *Iter, ok bool) {
+	iter = e.NewIter()
+	return iter, iter.Get(id)
+}
+
+// Alive returns whether the entity with the given id still exists.
+func (e *Entities) Alive(id EntityID) bool {
+	_, ok := e.slot(id)
+	return ok
+}
+
+type matchedBags struct {
+	q    query
+	bags []int
+}
+
+// matching returns the indices of all bags which meet the query q.
+func (e *Entities) matching(q *query) *matchedBags {
+	m, ok := e.matched[*q]
+	if !ok {
+		m = &matchedBags{q: *q}
+		for i, bag := range e.bags {
+			if q.matches(bag) {
+				m.bags = append(m.bags, i)
+			}
+		}
+		e.matched[*q] = m
+	}
+	return m
+}
+
+func newEntities() *Entities {
+	e := &Entities{
+		bagsByKey: make(map[compsKey]int),
+		matched:   make(map[query]*matchedBags),
+	}
+	e.Commands = &Commands{e: e}
+	return e
+}
+
+func (e *Entities) NewIter() *Iter {
+	return &Iter{
+		e: e,
+		i: -1,
+		j: -1,
+		m: -1,
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"log"
 	"math"
 	"math/rand"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/laremere/space-agon/game/pb"
@@ -28,26 +29,113 @@ type Game struct {
 	initialized   bool
 	NextNetworkId uint64
 
-	ControlledShip *Lookup
+	// Rand is the only source of randomness the simulation may use, so that
+	// games created with the same seed stay the same.
+	Rand       *rand.Rand
+	randSource *randSource
+	Seed       int64
+
+	// Tick is the number of ticks the game has run.  If FixedDt isn't zero, Step
+	// runs the game in ticks of exactly FixedDt, carrying over any leftover time
+	// to the next call.
+	Tick        uint64
+	FixedDt     float32
+	accumulated float32
+	// pendingMemos are memos given to Step which haven't been applied by a tick
+	// yet.
+	pendingMemos []*pb.Memo
+
+	ControlledShip EntityID
 	timeDead       float32
-	NetworkIds     map[uint64]*Lookup
+	NetworkIds     map[uint64]EntityID
+
+	// Scoreboard is kept by the host, and is the latest one received on
+	// clients, see score.go.
+	Scoreboard *pb.Scoreboard
+
+	// Rules are set on hosts which run a match, and Match is its current
+	// phase, see match.go.
+	Rules *pb.MatchRules
+	Match Match
+	// Mode is the game mode the host plays, see modes.go.
+	Mode GameMode
+
+	// ServerAuthoritative games have the host simulate every ship.  Clients
+	// predict their own ship from their controls, and correct it when the
+	// host's version arrives, see prediction.go.
+	ServerAuthoritative bool
+	// unacked are the controls the client has sent for its predicted ship which
+	// the host hasn't yet applied, oldest first.
+	unacked []predictedInput
+	// serverClock estimates the host's tick on clients of server authoritative
+	// games.
+	serverClock interpolationClock
+
+	// rollback is set if the host rewinds for late controls, see rollback.go.
+	rollback *rollback
+
+	// InterpolationDelay is how far, in seconds, NetworkReceive entities are
+	// shown behind their sender, see interpolation.go.
+	InterpolationDelay float32
+	interpolations     map[EntityID]*interpolated
+
+	spatial *spatialIndex
+
+	// Systems are run in order by Step.
+	Systems  []*System
+	Profiler *Profiler
 }
 
+// TickDt is the length of a tick of games created by NewDeterministicGame.
+const TickDt = float32(1) / 60
+
+// NewGame creates a game seeded from the clock, which steps by however long the
+// caller says has passed.
 func NewGame() *Game {
+	return newGame(time.Now().UnixNano())
+}
+
+// NewDeterministicGame creates a game which runs in fixed ticks.  Two games
+// created with the same seed and given the same inputs stay identical.
+func NewDeterministicGame(seed int64) *Game {
+	g := newGame(seed)
+	g.FixedDt = TickDt
+	return g
+}
+
+func newGame(seed int64) *Game {
+	r, src := newRand(seed)
 	g := &Game{
 		E: newEntities(),
 		// Oh man, this is such a bad hack.
-		NextNetworkId: uint64(rand.Int63()),
+		NextNetworkId: uint64(r.Int63()),
 		// NewClientUpdate: NewNetworkUpdate(),
 
+		Rand:       r,
+		randSource: src,
+		Seed:       seed,
+
 		timeDead:   100,
-		NetworkIds: make(map[uint64]*Lookup),
+		NetworkIds: make(map[uint64]EntityID),
+
+		Scoreboard: &pb.Scoreboard{},
+		Mode:       GameModes[0],
+
+		InterpolationDelay: DefaultInterpolationDelay,
+		interpolations:     make(map[EntityID]*interpolated),
+
+		spatial:  newSpatialIndex(),
+		Systems:  defaultSystems(),
+		Profiler: newProfiler(),
 	}
 
 	return g
 }
 
 func (g *Game) NextNid() uint64 {
+	if g.rollback != nil && g.rollback.current != nil {
+		return g.rollback.nid(g)
+	}
 	// TODO: ensure only being called by host somehow?
 	nid := g.NextNetworkId
 	g.NextNetworkId++
@@ -80,12 +168,11 @@ func (k *Keystate) Up() {
 }
 
 func getNid(g *Game, i *Iter, nid uint64) bool {
-	lookup, ok := g.NetworkIds[nid]
-	if !ok || !lookup.Alive() {
+	id, ok := g.NetworkIds[nid]
+	if !ok {
 		return false
 	}
-	i.Get(lookup)
-	return true
+	return i.Get(id)
 }
 
 type Input struct {
@@ -94,7 +181,10 @@ type Input struct {
 	Left  Keystate
 	Right Keystate
 	Fire  Keystate
-	Dt    float32
+	// Secondary fires the ship's Weapon, and SwitchWeapon changes its kind.
+	Secondary    Keystate
+	SwitchWeapon Keystate
+	Dt           float32
 	// Whether entities which only exist for render should be created.
 	IsRendered bool
 	// Whether code which runs only if the input is going to control
@@ -120,6 +210,15 @@ func (i *Input) SendTo(to int64, actual proto.Message) {
 	}, actual)
 }
 
+// SendToTeam sends to the players whose ships are on the team.
+func (i *Input) SendToTeam(team int32, actual proto.Message) {
+	i.SendMemo(&pb.Memo{
+		Recipient: &pb.Memo_Team{
+			Team: team,
+		},
+	}, actual)
+}
+
 func (i *Input) BroadcastOthers(actual proto.Message) {
 	i.SendMemo(&pb.Memo{
 		Recipient: &pb.Memo_EveryoneBut{
@@ -136,14 +235,8 @@ func (i *Input) BroadcastAll(actual proto.Message) {
 
 func (i *Input) SendMemo(partial *pb.Memo, actual proto.Message) {
 	switch a := actual.(type) {
-	case *pb.PosTracks:
-		partial.Actual = &pb.Memo_PosTracks{PosTracks: a}
-	case *pb.MomentumTracks:
-		partial.Actual = &pb.Memo_MomentumTracks{MomentumTracks: a}
-	case *pb.RotTracks:
-		partial.Actual = &pb.Memo_RotTracks{RotTracks: a}
-	case *pb.SpinTracks:
-		partial.Actual = &pb.Memo_SpinTracks{SpinTracks: a}
+	case *pb.Tracks:
+		partial.Actual = &pb.Memo_Tracks{Tracks: a}
 	case *pb.ShipControlTrack:
 		partial.Actual = &pb.Memo_ShipControlTrack{ShipControlTrack: a}
 	// case *pb.SpawnEvent:
@@ -160,6 +253,20 @@ func (i *Input) SendMemo(partial *pb.Memo, actual proto.Message) {
 		partial.Actual = &pb.Memo_SpawnShip{SpawnShip: a}
 	case *pb.RegisterPlayer:
 		partial.Actual = &pb.Memo_RegisterPlayer{RegisterPlayer: a}
+	case *pb.DamageEvent:
+		partial.Actual = &pb.Memo_DamageEvent{DamageEvent: a}
+	case *pb.Scoreboard:
+		partial.Actual = &pb.Memo_Scoreboard{Scoreboard: a}
+	case *pb.MatchState:
+		partial.Actual = &pb.Memo_MatchState{MatchState: a}
+	case *pb.FireWeapon:
+		partial.Actual = &pb.Memo_FireWeapon{FireWeapon: a}
+	case *pb.LaserBeam:
+		partial.Actual = &pb.Memo_LaserBeam{LaserBeam: a}
+	case *pb.SpawnMine:
+		partial.Actual = &pb.Memo_SpawnMine{SpawnMine: a}
+	case *pb.PlayerLeft:
+		partial.Actual = &pb.Memo_PlayerLeft{PlayerLeft: a}
 	default:
 		panic("Unknown memo actual type")
 	}
@@ -173,30 +280,132 @@ func (inp *Input) FrameEndReset() {
 	inp.Left.FrameEndReset()
 	inp.Right.FrameEndReset()
 	inp.Fire.FrameEndReset()
+	inp.Secondary.FrameEndReset()
+	inp.SwitchWeapon.FrameEndReset()
 }
 
 const ExplosionRadius = 2
 
+// MissileSpeed is how much faster than the ship which fired it a missile starts.
+const MissileSpeed = 13
+
+// missileHitRadius is how close a missile must come to something to explode on
+// it, near enough to its center that a direct hit does most of ExplosionDamage.
+const missileHitRadius = 0.6
+
+// ExplosionDamage is the damage done by an explosion at its center, falling off
+// to nothing at ExplosionRadius.
+const ExplosionDamage = 75
+
+const (
+	ShipHealth = 100
+	ShipShield = 40
+
+	// Shields start regenerating shieldRegenDelay seconds after they were last
+	// damaged, at shieldRegenRate a second.
+	shieldRegenDelay = 3
+	shieldRegenRate  = 10
+)
+
+// Step advances the game by input.Dt, applying input.Memos.
 func (g *Game) Step(input *Input) {
-	if g.ControlledShip.Alive() {
-		i := g.E.NewIter()
-		i.Get(g.ControlledShip)
+	g.latchInput(input)
+	if g.FixedDt == 0 {
+		g.tick(input)
+		return
+	}
+
+	g.pendingMemos = append(g.pendingMemos, input.Memos...)
+	g.accumulated += input.Dt
+	dt := input.Dt
+
+	if g.rollback != nil {
+		g.rewind(input)
+	}
+
+	for g.accumulated >= g.FixedDt {
+		g.accumulated -= g.FixedDt
+		input.Dt = g.FixedDt
+		input.Memos = g.pendingMemos
+		g.pendingMemos = nil
+		if g.rollback != nil {
+			g.recordTick(input)
+		} else {
+			g.tick(input)
+		}
+	}
+
+	input.Dt = dt
+	input.Memos = nil
+}
+
+func (g *Game) tick(input *Input) {
+	start := time.Now()
+	g.Profiler.start()
+	g.runTick(input, g.Profiler)
+	g.Profiler.finish(g.E, time.Since(start))
+}
+
+// runTick runs the systems for the next tick, timing each with the profiler
+// unless it is nil.
+func (g *Game) runTick(input *Input, p *Profiler) {
+	g.Tick++
 
-		shipControl := i.ShipControl()
+	roles := input.roles()
+	for _, s := range g.Systems {
+		if !s.runsFor(roles) {
+			continue
+		}
+		systemStart := time.Now()
+		s.Run(g, input)
+
+		// Commands are applied after every system, so the next system sees any
+		// spawns and removals.
+		g.E.Commands.Apply()
+		g.spatial.stale = true
+
+		if p != nil {
+			p.system(s.Name, time.Since(systemStart))
+		}
+	}
+}
+
+func (g *Game) applyInput(input *Input) {
+	if i, ok := g.E.Get(g.ControlledShip); ok {
+		shipControl := ShipControlKey.Get(i)
 		shipControl.Up = input.Up.Hold
 		shipControl.Down = input.Down.Hold
 		shipControl.Left = input.Left.Hold
 		shipControl.Right = input.Right.Hold
 		shipControl.Fire = input.Fire.Hold
+		shipControl.Secondary = input.Secondary.Hold
 	}
+}
+
+// latchInput keeps key presses on the controlled ship until a tick uses them.
+// Steps may run no ticks, or several, for one frame's input.
+func (g *Game) latchInput(input *Input) {
+	if i, ok := g.E.Get(g.ControlledShip); ok && input.SwitchWeapon.Press {
+		ShipControlKey.Get(i).SwitchWeapon = true
+	}
+}
 
+func (g *Game) applyMemos(input *Input) {
 	for _, memo := range input.Memos {
+		// Earlier memos may have moved, spawned or removed entities.
+		g.spatial.stale = true
+
 		switch actual := memo.Actual.(type) {
 		case *pb.Memo_DestroyEvent:
 			destroyEvent := actual.DestroyEvent
 
 			i := g.E.NewIter()
 			if getNid(g, i, destroyEvent.Nid) {
+				if input.IsHost {
+					// The host destroys ships itself, except for when a player flies
+					// their own into the sun in client authoritative games.
+					g.shipDestroyed(i, 0, input)
+				}
 				i.Remove()
 			}
 
@@ -219,58 +428,41 @@ func (g *Game) Step(input *Input) {
 		// 		panic("Spawn what now?")
 		// 	}
 
-		// 	*i.NetworkId() = spawnEvent.Nid
-		// 	g.NetworkIds[spawnEvent.Nid] = i.Lookup()
-
-		case *pb.Memo_PosTracks:
-			posTracks := actual.PosTracks
-			i := g.E.NewIter()
-
-			for index, nid := range posTracks.Nid {
-				if getNid(g, i, nid) {
-					*i.Pos() = Vec2{posTracks.X[index], posTracks.Y[index]}
-				}
-			}
-
-		case *pb.Memo_RotTracks:
-			rotTracks := actual.RotTracks
-			i := g.E.NewIter()
+		// 	*NetworkIdKey.Get(i) = spawnEvent.Nid
+		// 	g.NetworkIds[spawnEvent.Nid] = LookupKey.Get(i)
 
-			for index, nid := range rotTracks.Nid {
-				if getNid(g, i, nid) {
-					*i.Rot() = rotTracks.R[index]
-				}
-			}
-
-		case *pb.Memo_MomentumTracks:
-			momentumTracks := actual.MomentumTracks
-			i := g.E.NewIter()
-
-			for index, nid := range momentumTracks.Nid {
-				if getNid(g, i, nid) {
-					*i.Momentum() = Vec2{momentumTracks.X[index], momentumTracks.Y[index]}
-				}
-			}
-
-		case *pb.Memo_SpinTracks:
-			spinTracks := actual.SpinTracks
-			i := g.E.NewIter()
-
-			for index, nid := range spinTracks.Nid {
-				if getNid(g, i, nid) {
-					*i.Spin() = spinTracks.S[index]
-				}
+		case *pb.Memo_Tracks:
+			if g.ServerAuthoritative && !input.IsHost {
+				g.serverClock.received(actual.Tracks.Tick)
 			}
+			g.receiveTracks(input, actual.Tracks)
 
 		case *pb.Memo_ShipControlTrack:
 			shipControlTrack := actual.ShipControlTrack
 			i := g.E.NewIter()
 
 			if getNid(g, i, shipControlTrack.Nid) {
-				sc := i.ShipControl()
+				if i.Has(PredictedKey) {
+					// The host's echo of our own controls, the tracks sent with it are
+					// the ship's state after applying them.
+					g.reconcile(i, shipControlTrack.Seq)
+					break
+				}
+
+				sc := ShipControlKey.Get(i)
 				sc.Up = shipControlTrack.Up
+				sc.Down = shipControlTrack.Down
 				sc.Left = shipControlTrack.Left
 				sc.Right = shipControlTrack.Right
+
+				if g.ServerAuthoritative && input.IsHost {
+					sc.Fire = shipControlTrack.Fire
+					sc.Secondary = shipControlTrack.Secondary
+					sc.Seq = shipControlTrack.Seq
+					if w := WeaponKey.Get(i); w != nil && isSecondaryWeapon(shipControlTrack.Weapon) {
+						w.Kind = shipControlTrack.Weapon
+					}
+				}
 			}
 
 		case *pb.Memo_ShootMissile:
@@ -278,58 +470,11 @@ func (g *Game) Step(input *Input) {
 
 			i := g.E.NewIter()
 			if getNid(g, i, shootMissile.Owner) {
-
-				const MissileSpeed = 13
-				momentum := *i.Momentum()
-				momentum.AddEqual(Vec2FromRadians(*i.Rot()).Scale(MissileSpeed))
-
-				input.BroadcastAll(&pb.SpawnMissile{
-					Nid:      g.NextNid(),
-					Owner:    shootMissile.Owner,
-					Pos:      i.Pos().ToProto(),
-					Momentum: momentum.ToProto(),
-					Rot:      *i.Rot(),
-					Spin:     *i.Spin(),
-				})
+				g.shootMissile(i, 0, input)
 			}
 
 		case *pb.Memo_SpawnMissile:
-			spawnMissile := actual.SpawnMissile
-
-			i := g.E.NewIter()
-			if input.IsHost {
-				i.Require(NetworkTransmitKey)
-				i.Require(CanExplodeKey)
-				i.Require(TimedExplodeKey)
-			} else {
-				i.Require(NetworkReceiveKey)
-			}
-
-			i.Require(NetworkIdKey)
-			i.Require(PosKey)
-			i.Require(MomentumKey)
-			i.Require(RotKey)
-			i.Require(SpinKey)
-			i.Require(SpriteKey)
-			i.Require(AffectedByGravityKey)
-			i.Require(LookupKey)
-			i.Require(CanExplodeKey)
-			i.Require(MissileDetailsKey)
-
-			i.New()
-
-			if input.IsHost {
-				*i.TimedExplode() = 2
-			}
-
-			*i.NetworkId() = spawnMissile.Nid
-			g.NetworkIds[spawnMissile.Nid] = i.Lookup()
-			*i.Pos() = Vec2FromProto(spawnMissile.Pos)
-			*i.Momentum() = Vec2FromProto(spawnMissile.Momentum)
-			*i.Rot() = spawnMissile.Rot
-			*i.Spin() = spawnMissile.Spin
-			*i.Sprite() = SpriteMissile
-			i.MissileDetails().Owner = g.NetworkIds[spawnMissile.Owner]
+			g.spawnMissile(actual.SpawnMissile, input)
 
 		case *pb.Memo_SpawnExplosion:
 			spawnExplosion := actual.SpawnExplosion
@@ -339,26 +484,24 @@ func (g *Game) Step(input *Input) {
 
 			if input.IsHost {
 				i := g.E.NewIter()
-				i.Require(PosKey)
-				i.Require(CanExplodeKey)
-				i.Require(NetworkIdKey)
 
-				for i.Next() {
-					diff := pos.Sub(*i.Pos())
-					if diff.Length() < ExplosionRadius {
-						iMomentum := Vec2{}
-						if i.Momentum() != nil {
-							iMomentum = *i.Momentum()
+				team := g.teamOf(spawnExplosion.CausedBy)
+				for _, id := range g.QueryRadius(pos, ExplosionRadius) {
+					if !i.Get(id) || !i.Has(CanExplodeKey) || !i.Has(NetworkIdKey) {
+						continue
+					}
+					if t := TeamKey.Get(i); t != nil && g.harmless(team, *t) && !g.ownedBy(i, spawnExplosion.CausedBy) {
+						continue
+					}
+					if i.Has(HealthKey) {
+						diff := PosKey.Get(i).Sub(pos)
+						if g.damage(i, ExplosionDamage*(1-diff.Length()/ExplosionRadius), input) {
+							continue
 						}
-						input.BroadcastOthers(&pb.DestroyEvent{
-							Nid: *i.NetworkId(),
-						})
-						input.BroadcastAll(&pb.SpawnExplosion{
-							Pos:      i.Pos().ToProto(),
-							Momentum: iMomentum.ToProto(),
-						})
-						i.Remove()
 					}
+					// Whoever caused this explosion is also credited with whatever the
+					// next one destroys.
+					g.destroy(i, id, spawnExplosion.CausedBy, input)
 				}
 			}
 
@@ -371,17 +514,17 @@ func (g *Game) Step(input *Input) {
 				i.Require(ParticleSunDeleteKey)
 
 				for j := 0; j < 1000; j++ {
-					speed := rand.Float32()*10 + 0.01
-					dir := rand.Float32() * math.Pi * 2
-					ttl := 1.0/speed + rand.Float32()
+					speed := g.Rand.Float32()*10 + 0.01
+					dir := g.Rand.Float32() * math.Pi * 2
+					ttl := 1.0/speed + g.Rand.Float32()
 					if ttl > 3 {
 						ttl = 3
 					}
 
 					i.New()
-					*i.Pos() = pos.Add(Vec2FromRadians(rand.Float32() * math.Pi * 2).Scale(rand.Float32() * ExplosionRadius))
-					*i.Momentum() = momentum.Add(Vec2FromRadians(dir).Scale(speed))
-					*i.TimedDestroy() = ttl
+					*PosKey.Get(i) = pos.Add(Vec2FromRadians(g.Rand.Float32() * math.Pi * 2).Scale(g.Rand.Float32() * ExplosionRadius))
+					*MomentumKey.Get(i) = momentum.Add(Vec2FromRadians(dir).Scale(speed))
+					*TimedDestroyKey.Get(i) = ttl
 				}
 			}
 
@@ -393,48 +536,154 @@ func (g *Game) Step(input *Input) {
 				i.Require(SpriteKey)
 
 				i.New()
-				*i.Pos() = pos
-				*i.Momentum() = momentum
-				*i.TimedDestroy() = 0.07
-				*i.Sprite() = SpriteExplosionFlash
+				*PosKey.Get(i) = pos
+				*MomentumKey.Get(i) = momentum
+				*TimedDestroyKey.Get(i) = 0.07
+				*SpriteKey.Get(i) = SpriteExplosionFlash
+			}
+
+		case *pb.Memo_Scoreboard:
+			if !input.IsHost {
+				g.Scoreboard = actual.Scoreboard
+			}
+
+		case *pb.Memo_MatchState:
+			if !input.IsHost {
+				g.Match = Match{
+					Phase:    actual.MatchState.Phase,
+					TimeLeft: actual.MatchState.TimeLeft,
+				}
+			}
+
+		case *pb.Memo_DamageEvent:
+			damageEvent := actual.DamageEvent
+
+			// The host has already applied the damage.
+			if input.IsHost {
+				break
+			}
+
+			i := g.E.NewIter()
+			if getNid(g, i, damageEvent.Nid) {
+				if health := HealthKey.Get(i); health != nil {
+					*health = damageEvent.Health
+				}
+				if shield := ShieldKey.Get(i); shield != nil {
+					shield.Value = damageEvent.Shield
+					shield.SinceDamage = 0
+				}
+
+				if input.IsRendered {
+					g.spawnHitSparks(*PosKey.Get(i), *MomentumKey.Get(i), damageEvent.Damage)
+				}
+			}
+
+		case *pb.Memo_FireWeapon:
+			fireWeapon := actual.FireWeapon
+
+			i := g.E.NewIter()
+			if getNid(g, i, fireWeapon.Owner) && armed(i) {
+				g.fire(i, fireWeapon.Kind, input)
+			}
+
+		case *pb.Memo_LaserBeam:
+			if input.IsRendered {
+				g.spawnLaserBeam(Vec2FromProto(actual.LaserBeam.From), Vec2FromProto(actual.LaserBeam.To))
 			}
 
+		case *pb.Memo_SpawnMine:
+			spawnMine := actual.SpawnMine
+
+			i := g.E.NewIter()
+			if input.IsHost {
+				i.Require(NetworkTransmitKey)
+				i.Require(TimedExplodeKey)
+			} else {
+				i.Require(NetworkReceiveKey)
+			}
+
+			i.Require(NetworkIdKey)
+			i.Require(PosKey)
+			i.Require(MomentumKey)
+			i.Require(SpriteKey)
+			i.Require(AffectedByGravityKey)
+			i.Require(LookupKey)
+			i.Require(CanExplodeKey)
+			i.Require(MineDetailsKey)
+			i.Require(TeamKey)
+
+			i.New()
+
+			if input.IsHost {
+				*TimedExplodeKey.Get(i) = mineLifetime
+			}
+
+			*NetworkIdKey.Get(i) = spawnMine.Nid
+			g.NetworkIds[spawnMine.Nid] = LookupKey.Get(i)
+			*PosKey.Get(i) = Vec2FromProto(spawnMine.Pos)
+			*MomentumKey.Get(i) = Vec2FromProto(spawnMine.Momentum)
+			*SpriteKey.Get(i) = SpriteMine
+			*MineDetailsKey.Get(i) = MineDetails{
+				OwnerCid: spawnMine.OwnerCid,
+				Arming:   mineArmTime,
+			}
+			*TeamKey.Get(i) = spawnMine.Team
+
 		case *pb.Memo_SpawnShip:
 			spawnShip := actual.SpawnShip
 
+			// Ships on a team spawn at the free point closest to a teammate, others
+			// at the point farthest from anything.
 			var pos Vec2
 			var r float32
 			{
 				type possibility struct {
-					pos     Vec2
-					r       float32
-					closest float32
+					pos      Vec2
+					r        float32
+					closest  float32
+					teammate float32
 				}
 				possibilities := []possibility{}
 				for r := float32(0); r < math.Pi*2; r += math.Pi / 6 {
 					possibilities = append(possibilities, possibility{
-						pos:     Vec2FromRadians(r).Scale(14),
-						r:       r,
-						closest: float32(math.Inf(1)),
+						pos:      Vec2FromRadians(r).Scale(14),
+						r:        r,
+						closest:  float32(math.Inf(1)),
+						teammate: float32(math.Inf(1)),
 					})
 				}
 				i := g.E.NewIter()
 				i.Require(PosKey)
 				i.Require(CanExplodeKey)
 
+				teammates := false
 				for i.Next() {
+					team := TeamKey.Get(i)
+					teammate := spawnShip.Team != 0 && team != nil && *team == spawnShip.Team && i.Has(ShipControlKey)
+					teammates = teammates || teammate
+
 					for j := range possibilities {
-						diff := i.Pos().Sub(possibilities[j].pos)
+						diff := PosKey.Get(i).Sub(possibilities[j].pos)
 						dist := diff.Length()
 						if dist < possibilities[j].closest {
 							possibilities[j].closest = dist
 						}
+						if teammate && dist < possibilities[j].teammate {
+							possibilities[j].teammate = dist
+						}
 					}
 				}
 
+				// Points closer than this to something aren't free.
+				const clearance = 2
+
 				best := possibilities[0]
 				for _, j := range possibilities[1:] {
-					if j.closest > best.closest {
+					if teammates && j.closest > clearance {
+						if best.closest <= clearance || j.teammate < best.teammate {
+							best = j
+						}
+					} else if j.closest > best.closest {
 						best = j
 					}
 				}
@@ -444,9 +693,16 @@ func (g *Game) Step(input *Input) {
 			}
 
 			i := g.E.NewIter()
-			if spawnShip.Authority == input.Cid {
+			switch {
+			case input.IsHost && IsBot(spawnShip.Authority):
 				i.Require(NetworkTransmitKey)
-			} else {
+			case g.ServerAuthoritative && input.IsHost:
+				i.Require(NetworkTransmitKey)
+			case g.ServerAuthoritative && spawnShip.Authority == input.Cid:
+				i.Require(PredictedKey)
+			case spawnShip.Authority == input.Cid:
+				i.Require(NetworkTransmitKey)
+			default:
 				i.Require(NetworkReceiveKey)
 			}
 
@@ -462,49 +718,86 @@ func (g *Game) Step(input *Input) {
 			i.Require(NetworkIdKey)
 			i.Require(BoundLocationKey)
 			i.Require(CanExplodeKey)
+			i.Require(HealthKey)
+			i.Require(ShieldKey)
+			i.Require(AuthorityKey)
+			i.Require(TeamKey)
+			i.Require(WeaponKey)
 			i.New()
 
-			// *i.Pos() = Vec2FromProto(spawnShip.Pos)
-			// *i.Momentum() = Vec2FromProto(spawnShip.Momentum)
-			*i.Pos() = pos
-			*i.Momentum() = Vec2FromRadians(r + math.Pi/2).Scale(3.5)
-			*i.Rot() = r + math.Pi/2
-			*i.Spin() = spawnShip.Spin
-			// pos := i.Pos()
+			*AuthorityKey.Get(i) = spawnShip.Authority
+			*TeamKey.Get(i) = spawnShip.Team
+			*WeaponKey.Get(i) = newWeapon()
+			*HealthKey.Get(i) = ShipHealth
+			*ShieldKey.Get(i) = Shield{
+				Value: ShipShield,
+				Max:   ShipShield,
+			}
+			// *PosKey.Get(i) = Vec2FromProto(spawnShip.Pos)
+			// *MomentumKey.Get(i) = Vec2FromProto(spawnShip.Momentum)
+			*PosKey.Get(i) = pos
+			*MomentumKey.Get(i) = Vec2FromRadians(r + math.Pi/2).Scale(3.5)
+			*RotKey.Get(i) = r + math.Pi/2
+			*SpinKey.Get(i) = spawnShip.Spin
+			// pos := PosKey.Get(i)
 			// (*pos)[0] = 7
 			// (*pos)[1] = 0
-			// (*i.Momentum())[1] = 5
-			// *i.Rot() = 0
-			// *i.NetworkId() = g.NextNetworkId
+			// (*MomentumKey.Get(i))[1] = 5
+			// *RotKey.Get(i) = 0
+			// *NetworkIdKey.Get(i) = g.NextNetworkId
 			// g.NextNetworkId++
 
-			*i.NetworkId() = spawnShip.Nid
-			g.NetworkIds[spawnShip.Nid] = i.Lookup()
+			*NetworkIdKey.Get(i) = spawnShip.Nid
+			g.NetworkIds[spawnShip.Nid] = LookupKey.Get(i)
+
+			if input.IsHost && IsBot(spawnShip.Authority) {
+				g.botSpawned(spawnShip.Authority, LookupKey.Get(i))
+			}
 
 			if spawnShip.Authority == input.Cid {
-				g.ControlledShip = i.Lookup()
-				*i.Sprite() = SpriteShip
+				g.ControlledShip = LookupKey.Get(i)
+				*SpriteKey.Get(i) = SpriteShip
 			} else {
-				*i.Sprite() = SpriteEnemyShip
+				*SpriteKey.Get(i) = SpriteEnemyShip
 			}
 
 		case *pb.Memo_RegisterPlayer:
 			registerPlayer := actual.RegisterPlayer
 
+			if input.IsHost && IsBot(registerPlayer.Cid) {
+				g.addBot(registerPlayer.Cid)
+			}
+
+			if !g.canSpawn() || !g.playerJoined(registerPlayer, input) {
+				break
+			}
+
 			input.BroadcastAll(&pb.SpawnShip{
 				Nid:       g.NextNid(),
 				Authority: registerPlayer.Cid,
+				Team:      g.teamOf(registerPlayer.Cid),
 				// Pos:       (&Vec2{14, 0}).ToProto(),
 				// Momentum:  (&Vec2{0, 3.5}).ToProto(),
 				// Rot:  0,
 				// Spin: 0,
 			})
 
+		case *pb.Memo_PlayerLeft:
+			if input.IsHost {
+				g.playerLeft(actual.PlayerLeft.Cid, input)
+			}
+
 		default:
 			log.Fatal("Unknown message type:", actual)
 		}
+
+		// Applied after each memo, so that a later memo can't act on an entity
+		// an earlier one has removed.
+		g.E.Commands.Apply()
 	}
+}
 
+func (g *Game) initialize(input *Input) {
 	if !g.initialized {
 		if input.IsRendered { // spawn stars
 			{ // Big star
@@ -513,7 +806,7 @@ func (g *Game) Step(input *Input) {
 				i.Require(SpriteKey)
 				i.New()
 
-				*i.Sprite() = SpriteStar
+				*SpriteKey.Get(i) = SpriteStar
 			}
 
 			{
@@ -526,10 +819,10 @@ func (g *Game) Step(input *Input) {
 				const starBoxRadius = 200
 				for j := 0; j < int(density*starBoxRadius*starBoxRadius); j++ {
 					i.New()
-					// *i.Sprite() = SpriteStarBit
-					*i.Pos() = Vec2{
-						rand.Float32()*starBoxRadius*2 - starBoxRadius,
-						rand.Float32()*starBoxRadius*2 - starBoxRadius,
+					// *SpriteKey.Get(i) = SpriteStarBit
+					*PosKey.Get(i) = Vec2{
+						g.Rand.Float32()*starBoxRadius*2 - starBoxRadius,
+						g.Rand.Float32()*starBoxRadius*2 - starBoxRadius,
 					}
 				}
 			}
@@ -537,143 +830,141 @@ func (g *Game) Step(input *Input) {
 
 		g.initialized = true
 	}
+}
 
-	if input.IsPlayer && input.IsConnected { // spawn/respawn
-		if !g.ControlledShip.Alive() {
-			g.timeDead += input.Dt
+func (g *Game) respawn(input *Input) {
+	if input.IsConnected && !g.E.Alive(g.ControlledShip) {
+		g.timeDead += input.Dt
 
-			if g.timeDead > 4 {
-				g.timeDead = 0
+		if g.timeDead > 4 {
+			g.timeDead = 0
 
-				input.SendTo(0, &pb.RegisterPlayer{
-					Cid: input.Cid,
-				})
-			}
+			input.SendTo(0, &pb.RegisterPlayer{
+				Cid: input.Cid,
+			})
 		}
 	}
+}
 
-	// Explosions cause more explosions
-	// for {
-	// 	newExplosions := [][2]Vec2(nil)
-	// 	{
-	// 		i := g.E.NewIter()
-	// 		i.Require(PosKey)
-	// 		i.Require(ExplosionDetailsKey)
-
-	// 		for i.Next() {
-	// 			if !i.ExplosionDetails().MoreExplosions {
-	// 				i.ExplosionDetails().MoreExplosions = true
-	// 				other := g.E.NewIter()
-	// 				other.Require(PosKey)
-	// 				other.Require(LookupKey)
-	// 				other.Require(CanExplodeKey)
-	// 				other.Require(NetworkTransmitKey)
-	// 				for other.Next() {
-	// 					if i.Lookup() == other.Lookup() {
-	// 						continue
-	// 					}
-	// 					diff := i.Pos().Sub(*other.Pos())
-	// 					if diff.Length() < 1 {
-	// 						newExplosions = append(newExplosions, [2]Vec2{*other.Pos(), *other.Momentum()})
-	// 						if other.NetworkId() != nil {
-
-	// 							input.BroadcastOthers(&pb.DestroyEvent{
-	// 								Nid: uint64(*other.NetworkId()),
-	// 							})
-
-	// 							other.Remove()
-	// 							break
-	// 						}
-	// 					}
-
-	// 				}
-	// 			}
-	// 		}
-
-	// 		if len(newExplosions) == 0 {
-	// 			break
-	// 		}
-
-	// 		for _, posMomentum := range newExplosions {
-	// 			ie := g.E.NewIter()
-	// 			ie.Require(NetworkTransmitKey)
-	// 			ie.Require(TimedDestroyKey)
-	// 			spawnExplosion(ie)
-	// 			*ie.Pos() = posMomentum[0]
-	// 			*ie.Momentum() = posMomentum[1]
-	// 			*ie.NetworkId() = g.NextNetworkId
-	// 			*ie.TimedDestroy() = 0.5
-	// 			g.NextNetworkId++
-
-	// 			input.BroadcastOthers(&pb.SpawnEvent{
-	// 				Nid:       uint64(*ie.NetworkId()),
-	// 				SpawnType: pb.SpawnEvent_EXPLOSION,
-	// 			})
-	// 		}
-	// 	}
-	// }
-
-	// if input.IsRendered { // explosion fun :D
-	// 	i := g.E.NewIter()
-	// 	i.Require(PosKey)
-	// 	i.Require(MomentumKey)
-	// 	i.Require(ExplosionDetailsKey)
-
-	// 	pi := g.E.NewIter() // particle iter
-	// 	pi.Require(PosKey)
-	// 	pi.Require(MomentumKey)
-	// 	pi.Require(TimedDestroyKey)
-	// 	pi.Require(PointRenderKey)
-	// 	pi.Require(ParticleSunDeleteKey)
-
-	// 	for i.Next() {
-	// 		if !i.ExplosionDetails().Initialized {
-	// 			i.ExplosionDetails().Initialized = true
-	// 			for j := 0; j < 1000; j++ {
-	// 				speed := rand.Float32()*10 + 0.01
-	// 				dir := rand.Float32() * math.Pi * 2
-	// 				ttl := 1.0/speed + rand.Float32()
-	// 				if ttl > 3 {
-	// 					ttl = 3
-	// 				}
-
-	// 				pi.New()
-	// 				*pi.Pos() = *i.Pos()
-	// 				*pi.Momentum() = i.Momentum().Add(Vec2FromRadians(dir).Scale(speed))
-	// 				*pi.TimedDestroy() = ttl
-	// 			}
-	// 		}
-	// 	}
-	// }
-
-	if input.IsRendered { // Spawn sun particles
-		i := g.E.NewIter()
-		i.Require(PosKey)
-		i.Require(PointRenderKey)
-		i.Require(MomentumKey)
-		i.Require(TimedDestroyKey)
+// Explosions cause more explosions
+// for {
+// 	newExplosions := [][2]Vec2(nil)
+// 	{
+// 		i := g.E.NewIter()
+// 		i.Require(PosKey)
+// 		i.Require(ExplosionDetailsKey)
+
+// 		for i.Next() {
+// 			if !i.ExplosionDetails().MoreExplosions {
+// 				i.ExplosionDetails().MoreExplosions = true
+// 				other := g.E.NewIter()
+// 				other.Require(PosKey)
+// 				other.Require(LookupKey)
+// 				other.Require(CanExplodeKey)
+// 				other.Require(NetworkTransmitKey)
+// 				for other.Next() {
+// 					if LookupKey.Get(i) == LookupKey.Get(other) {
+// 						continue
+// 					}
+// 					diff := PosKey.Get(i).Sub(*PosKey.Get(other))
+// 					if diff.Length() < 1 {
+// 						newExplosions = append(newExplosions, [2]Vec2{*PosKey.Get(other), *MomentumKey.Get(other)})
+// 						if NetworkIdKey.Get(other) != nil {
+
+// 							input.BroadcastOthers(&pb.DestroyEvent{
+// 								Nid: uint64(*NetworkIdKey.Get(other)),
+// 							})
+
+// 							other.Remove()
+// 							break
+// 						}
+// 					}
+
+// 				}
+// 			}
+// 		}
+
+// 		if len(newExplosions) == 0 {
+// 			break
+// 		}
+
+// 		for _, posMomentum := range newExplosions {
+// 			ie := g.E.NewIter()
+// 			ie.Require(NetworkTransmitKey)
+// 			ie.Require(TimedDestroyKey)
+// 			spawnExplosion(ie)
+// 			*PosKey.Get(ie) = posMomentum[0]
+// 			*MomentumKey.Get(ie) = posMomentum[1]
+// 			*NetworkIdKey.Get(ie) = g.NextNetworkId
+// 			*TimedDestroyKey.Get(ie) = 0.5
+// 			g.NextNetworkId++
+
+// 			input.BroadcastOthers(&pb.SpawnEvent{
+// 				Nid:       uint64(*NetworkIdKey.Get(ie)),
+// 				SpawnType: pb.SpawnEvent_EXPLOSION,
+// 			})
+// 		}
+// 	}
+// }
 
-		for j := 0; j < 10; j++ {
-			i.New()
-			rad := rand.Float32() * 2 * math.Pi
-			*i.Pos() = Vec2FromRadians(rad)
-			rad += rand.Float32()*2 - 1
-			*i.Momentum() = Vec2FromRadians(rad).Scale(rand.Float32()*5 + 1)
-			*i.TimedDestroy() = rand.Float32()*2 + 1
-		}
+// if input.IsRendered { // explosion fun :D
+// 	i := g.E.NewIter()
+// 	i.Require(PosKey)
+// 	i.Require(MomentumKey)
+// 	i.Require(ExplosionDetailsKey)
+
+// 	pi := g.E.NewIter() // particle iter
+// 	pi.Require(PosKey)
+// 	pi.Require(MomentumKey)
+// 	pi.Require(TimedDestroyKey)
+// 	pi.Require(PointRenderKey)
+// 	pi.Require(ParticleSunDeleteKey)
+
+// 	for i.Next() {
+// 		if !i.ExplosionDetails().Initialized {
+// 			i.ExplosionDetails().Initialized = true
+// 			for j := 0; j < 1000; j++ {
+// 				speed := g.Rand.Float32()*10 + 0.01
+// 				dir := g.Rand.Float32() * math.Pi * 2
+// 				ttl := 1.0/speed + g.Rand.Float32()
+// 				if ttl > 3 {
+// 					ttl = 3
+// 				}
+
+// 				pi.New()
+// 				*PosKey.Get(pi) = *PosKey.Get(i)
+// 				*MomentumKey.Get(pi) = MomentumKey.Get(i).Add(Vec2FromRadians(dir).Scale(speed))
+// 				*TimedDestroyKey.Get(pi) = ttl
+// 			}
+// 		}
+// 	}
+// }
+
+func (g *Game) spawnSunParticles(input *Input) {
+	i := g.E.NewIter()
+	i.Require(PosKey)
+	i.Require(PointRenderKey)
+	i.Require(MomentumKey)
+	i.Require(TimedDestroyKey)
+
+	for j := 0; j < 10; j++ {
+		i.New()
+		rad := g.Rand.Float32() * 2 * math.Pi
+		*PosKey.Get(i) = Vec2FromRadians(rad)
+		rad += g.Rand.Float32()*2 - 1
+		*MomentumKey.Get(i) = Vec2FromRadians(rad).Scale(g.Rand.Float32()*5 + 1)
+		*TimedDestroyKey.Get(i) = g.Rand.Float32()*2 + 1
 	}
+}
 
+func (g *Game) timedDestroy(input *Input) {
 	{
 		i := g.E.NewIter()
 		i.Require(TimedDestroyKey)
+		i.Exclude(NetworkIdKey)
 		for i.Next() {
-			*i.TimedDestroy() -= input.Dt
-			if *i.TimedDestroy() <= 0 {
-				if i.NetworkId() != nil {
-					input.BroadcastOthers(&pb.DestroyEvent{
-						Nid: uint64(*i.NetworkId()),
-					})
-				}
+			*TimedDestroyKey.Get(i) -= input.Dt
+			if *TimedDestroyKey.Get(i) <= 0 {
 				i.Remove()
 			}
 		}
@@ -681,376 +972,463 @@ func (g *Game) Step(input *Input) {
 
 	{
 		i := g.E.NewIter()
-		i.Require(TimedExplodeKey)
-		i.Require(PosKey)
-		i.Require(MomentumKey)
+		i.Require(TimedDestroyKey)
 		i.Require(NetworkIdKey)
 		for i.Next() {
-			*i.TimedExplode() -= input.Dt
-			if *i.TimedExplode() <= 0 {
+			*TimedDestroyKey.Get(i) -= input.Dt
+			if *TimedDestroyKey.Get(i) <= 0 {
 				input.BroadcastOthers(&pb.DestroyEvent{
-					Nid: *i.NetworkId(),
-				})
-				input.BroadcastAll(&pb.SpawnExplosion{
-					Pos:      i.Pos().ToProto(),
-					Momentum: i.Momentum().ToProto(),
-				})
-				i.Remove()
-			}
-		}
-	}
-
-	{ // Explode in the sun
-		i := g.E.NewIter()
-		i.Require(CanExplodeKey)
-		i.Require(PosKey)
-		i.Require(NetworkTransmitKey)
-		for i.Next() {
-			if i.Pos().Length() < 3 {
-				input.BroadcastOthers(&pb.DestroyEvent{
-					Nid: *i.NetworkId(),
-				})
-				input.BroadcastAll(&pb.SpawnExplosion{
-					Pos:      i.Pos().ToProto(),
-					Momentum: i.Momentum().ToProto(),
+					Nid: *NetworkIdKey.Get(i),
 				})
 				i.Remove()
 			}
 		}
 	}
+}
 
-	{ // Explode When colliding
-		i := g.E.NewIter()
-		i.Require(MissileDetailsKey)
-		i.Require(PosKey)
-		i.Require(LookupKey)
-		i.Require(NetworkTransmitKey)
-		for i.Next() {
-			other := g.E.NewIter()
-			other.Require(PosKey)
-			other.Require(LookupKey)
-			other.Require(CanExplodeKey)
-			for other.Next() {
-				if i.Lookup() == other.Lookup() || i.MissileDetails().Owner == other.Lookup() {
-					continue
-				}
-				diff := i.Pos().Sub(*other.Pos())
-				if diff.Length() < ExplosionRadius*0.8 {
-					input.BroadcastOthers(&pb.DestroyEvent{
-						Nid: *i.NetworkId(),
-					})
-					input.BroadcastAll(&pb.SpawnExplosion{
-						Pos:      i.Pos().ToProto(),
-						Momentum: i.Momentum().ToProto(),
-					})
-					i.Remove()
-					break
-				}
-			}
+func (g *Game) timedExplode(input *Input) {
+	i := g.E.NewIter()
+	i.Require(TimedExplodeKey)
+	i.Require(PosKey)
+	i.Require(MomentumKey)
+	i.Require(NetworkIdKey)
+	for i.Next() {
+		*TimedExplodeKey.Get(i) -= input.Dt
+		if *TimedExplodeKey.Get(i) <= 0 {
+			input.BroadcastOthers(&pb.DestroyEvent{
+				Nid: *NetworkIdKey.Get(i),
+			})
+			input.BroadcastAll(&pb.SpawnExplosion{
+				Nid:      *NetworkIdKey.Get(i),
+				Pos:      PosKey.Get(i).ToProto(),
+				Momentum: MomentumKey.Get(i).ToProto(),
+				CausedBy: causedBy(i),
+			})
+			i.Remove()
 		}
 	}
+}
 
-	{
-		i := g.E.NewIter()
-		i.Require(PosKey)
-		i.Require(ParticleSunDeleteKey)
-		for i.Next() {
-			if i.Pos().Length() < 2.3 {
-				i.Remove()
-			}
+func (g *Game) sunExplode(input *Input) {
+	i := g.E.NewIter()
+	i.Require(CanExplodeKey)
+	i.Require(PosKey)
+	i.Require(NetworkTransmitKey)
+	for i.Next() {
+		if PosKey.Get(i).Length() < 3 {
+			g.shipDestroyed(i, 0, input)
+			input.BroadcastOthers(&pb.DestroyEvent{
+				Nid: *NetworkIdKey.Get(i),
+			})
+			input.BroadcastAll(&pb.SpawnExplosion{
+				Nid:      *NetworkIdKey.Get(i),
+				Pos:      PosKey.Get(i).ToProto(),
+				Momentum: MomentumKey.Get(i).ToProto(),
+			})
+			i.Remove()
 		}
 	}
+}
 
-	{
-		i := g.E.NewIter()
-		i.Require(PosKey)
-		i.Require(RotKey)
-		i.Require(ShipControlKey)
-		i.Require(SpinKey)
-		i.Require(MomentumKey)
-		i.Require(LookupKey)
-		i.Require(NetworkIdKey)
-		for i.Next() {
-			///////////////////////////
-			// Ship Movement Controls
-			///////////////////////////
-			const rotationForSpeed = 5
-			const rotationAgainstSpeed = 10
-			const forwardSpeed = 4
-
-			spinDesire := float32(0)
-			if i.ShipControl().Left {
-				spinDesire++
-			}
-			if i.ShipControl().Right {
-				spinDesire--
-			}
-			if !i.ShipControl().Left && !i.ShipControl().Right {
-				s := *i.Spin()
-				if s < -0.5 {
-					spinDesire += 0.1
-				} else if s > 0.5 {
-					spinDesire -= 0.1
-				}
-			}
-
-			// Game feel: Stopping spin is easier than starting it.
-			if (spinDesire < 0) == (*i.Spin() < 0) {
-				spinDesire *= rotationForSpeed
-			} else {
-				spinDesire *= rotationAgainstSpeed
+func (g *Game) missileCollision(input *Input) {
+	i := g.E.NewIter()
+	i.Require(MissileDetailsKey)
+	i.Require(PosKey)
+	i.Require(LookupKey)
+	i.Require(TeamKey)
+	i.Require(NetworkTransmitKey)
+	other := g.E.NewIter()
+	for i.Next() {
+		for _, id := range g.QueryRadius(*PosKey.Get(i), missileHitRadius) {
+			if LookupKey.Get(i) == id || MissileDetailsKey.Get(i).Owner == id {
+				continue
 			}
-
-			*i.Spin() += spinDesire * input.Dt
-
-			if i.ShipControl().Up {
-				dx := float32(math.Cos(float64(*i.Rot()))) * forwardSpeed * input.Dt
-				dy := float32(math.Sin(float64(*i.Rot()))) * forwardSpeed * input.Dt
-
-				(*i.Momentum())[0] += dx
-				(*i.Momentum())[1] += dy
+			if !other.Get(id) || !other.Has(CanExplodeKey) {
+				continue
 			}
-
-			///////////////////////////
-			// Ship Weapons
-			///////////////////////////
-			i.ShipControl().FireCoolDown -= input.Dt
-
-			if i.ShipControl().FireCoolDown <= 0 && i.ShipControl().Fire {
-				input.SendTo(0, &pb.ShootMissile{
-					Owner: *i.NetworkId(),
-				})
-
-				i.ShipControl().FireCoolDown = 0.5
-				// i.ShipControl().FireCoolDown = 5
+			if t := TeamKey.Get(other); t != nil && g.harmless(*TeamKey.Get(i), *t) {
+				continue
 			}
+			input.BroadcastOthers(&pb.DestroyEvent{
+				Nid: *NetworkIdKey.Get(i),
+			})
+			input.BroadcastAll(&pb.SpawnExplosion{
+				Nid:      *NetworkIdKey.Get(i),
+				Pos:      PosKey.Get(i).ToProto(),
+				Momentum: MomentumKey.Get(i).ToProto(),
+				CausedBy: causedBy(i),
+			})
+			g.E.Commands.Remove(LookupKey.Get(i))
+			break
 		}
 	}
+}
 
-	if input.IsRendered { // Spawn ship movement particles
-		i := g.E.NewIter()
-		i.Require(PosKey)
-		i.Require(RotKey)
-		i.Require(ShipControlKey)
-		i.Require(MomentumKey)
-
-		ip := g.E.NewIter()
-		ip.Require(PosKey)
-		ip.Require(PointRenderKey)
-		ip.Require(MomentumKey)
-		ip.Require(TimedDestroyKey)
-		ip.Require(ParticleSunDeleteKey)
+// destroy blows up the entity the iter is pointing at, which has the id, and
+// credits causedBy with it.
+func (g *Game) destroy(i *Iter, id EntityID, causedBy int64, input *Input) {
+	iMomentum := Vec2{}
+	if MomentumKey.Get(i) != nil {
+		iMomentum = *MomentumKey.Get(i)
+	}
+	g.shipDestroyed(i, causedBy, input)
+	input.BroadcastOthers(&pb.DestroyEvent{
+		Nid: *NetworkIdKey.Get(i),
+	})
+	input.BroadcastAll(&pb.SpawnExplosion{
+		Nid:      *NetworkIdKey.Get(i),
+		Pos:      PosKey.Get(i).ToProto(),
+		Momentum: iMomentum.ToProto(),
+		CausedBy: causedBy,
+	})
+	g.E.Commands.Remove(id)
+}
 
-		for i.Next() {
-			const pushFactor = 5
-			emitPoint := i.Pos().Sub(Vec2FromRadians(*i.Rot()).Scale(0.4))
+// damage takes the amount from the shield and then the health of the entity
+// the iter is pointing at, and tells everyone.  It returns false if the entity
+// has no health left.
+func (g *Game) damage(i *Iter, amount float32, input *Input) bool {
+	if amount <= 0 {
+		return true
+	}
 
-			if i.ShipControl().Up {
-				ip.New()
-				*ip.Pos() = emitPoint
+	health := HealthKey.Get(i)
+	remaining := amount
+	shield := ShieldKey.Get(i)
+	if shield != nil {
+		shield.SinceDamage = 0
+		absorbed := remaining
+		if absorbed > shield.Value {
+			absorbed = shield.Value
+		}
+		shield.Value -= absorbed
+		remaining -= absorbed
+	}
+	*health -= remaining
 
-				angleOut := *i.Rot() + math.Pi + (rand.Float32()-0.5)/3
-				*ip.Momentum() = i.Momentum().Add(Vec2FromRadians(angleOut).Scale(pushFactor))
-				*ip.TimedDestroy() = rand.Float32()*2 + 1
-			}
-			if i.ShipControl().Left {
-				ip.New()
-				*ip.Pos() = emitPoint
+	damageEvent := &pb.DamageEvent{
+		Nid:    *NetworkIdKey.Get(i),
+		Damage: amount,
+		Health: *health,
+	}
+	if shield != nil {
+		damageEvent.Shield = shield.Value
+	}
+	input.BroadcastAll(damageEvent)
 
-				angleOut := *i.Rot() + math.Pi/2 + (rand.Float32()-0.5)/3
-				*ip.Momentum() = i.Momentum().Add(Vec2FromRadians(angleOut).Scale(pushFactor))
-				*ip.TimedDestroy() = rand.Float32()*2 + 1
-			}
-			if i.ShipControl().Right {
-				ip.New()
-				*ip.Pos() = emitPoint
+	return *health > 0
+}
 
-				angleOut := *i.Rot() + math.Pi*3/2 + (rand.Float32()-0.5)/3
-				*ip.Momentum() = i.Momentum().Add(Vec2FromRadians(angleOut).Scale(pushFactor))
-				*ip.TimedDestroy() = rand.Float32()*2 + 1
+func (g *Game) regenerateShields(input *Input) {
+	i := g.E.NewIter()
+	i.Require(ShieldKey)
+	for i.Next() {
+		shield := ShieldKey.Get(i)
+		shield.SinceDamage += input.Dt
+		if shield.SinceDamage > shieldRegenDelay {
+			shield.Value += shieldRegenRate * input.Dt
+			if shield.Value > shield.Max {
+				shield.Value = shield.Max
 			}
 		}
 	}
+}
 
-	{
-		i := g.E.NewIter()
-		i.Require(RotKey)
-		i.Require(MomentumKey)
-		i.Require(MissileDetailsKey)
+// spawnHitSparks shows that an entity at pos has taken damage, more sparks for
+// more damage.
+func (g *Game) spawnHitSparks(pos Vec2, momentum Vec2, damage float32) {
+	i := g.E.NewIter()
+	i.Require(PosKey)
+	i.Require(MomentumKey)
+	i.Require(TimedDestroyKey)
+	i.Require(PointRenderKey)
+	i.Require(ParticleSunDeleteKey)
+
+	for j := 0; j < int(damage*2); j++ {
+		speed := g.Rand.Float32()*4 + 1
+		dir := g.Rand.Float32() * math.Pi * 2
+
+		i.New()
+		*PosKey.Get(i) = pos
+		*MomentumKey.Get(i) = momentum.Add(Vec2FromRadians(dir).Scale(speed))
+		*TimedDestroyKey.Get(i) = g.Rand.Float32()*0.5 + 0.2
+	}
+}
 
-		for i.Next() {
-			const pushFactor = 10
-			i.Momentum().AddEqual(Vec2FromRadians(*i.Rot()).Scale(pushFactor * input.Dt))
+func (g *Game) particleSunDelete(input *Input) {
+	i := g.E.NewIter()
+	i.Require(PosKey)
+	i.Require(ParticleSunDeleteKey)
+	for i.Next() {
+		if PosKey.Get(i).Length() < 2.3 {
+			i.Remove()
 		}
 	}
+}
 
-	if input.IsRendered { // Spawn missile trail particles
-		i := g.E.NewIter()
-		i.Require(RotKey)
-		i.Require(MissileDetailsKey)
-		i.Require(PosKey)
-
-		ip := g.E.NewIter()
-		ip.Require(PosKey)
-		ip.Require(PointRenderKey)
-		ip.Require(MomentumKey)
-		ip.Require(TimedDestroyKey)
-		ip.Require(ParticleSunDeleteKey)
-
-		for i.Next() {
-			const pushFactor = 5
+func (g *Game) shipControls(input *Input) {
+	i := g.E.NewIter()
+	i.Require(PosKey)
+	i.Require(RotKey)
+	i.Require(ShipControlKey)
+	i.Require(SpinKey)
+	i.Require(MomentumKey)
+	i.Require(LookupKey)
+	i.Require(NetworkIdKey)
+	for i.Next() {
+		steerShip(i, input.Dt)
+
+		///////////////////////////
+		// Ship Weapons
+		///////////////////////////
+		ShipControlKey.Get(i).FireCoolDown -= input.Dt
+		if i.Has(WeaponKey) {
+			g.fireWeapon(i, input)
+		}
 
-			for j := 0; j < 4; j++ {
-				ip.New()
-				*ip.Pos() = *i.Pos()
+		if i.Has(PredictedKey) {
+			continue
+		}
 
-				angleOut := *i.Rot() + math.Pi + (rand.Float32()-0.5)/2
-				*ip.Momentum() = i.Momentum().Add(Vec2FromRadians(angleOut).Scale(pushFactor))
-				ip.Pos().AddEqual(ip.Momentum().Scale(float32(j) * input.Dt / 4))
-				*ip.TimedDestroy() = rand.Float32()*2 + 1
+		if ShipControlKey.Get(i).FireCoolDown <= 0 && ShipControlKey.Get(i).Fire {
+			if input.IsHost {
+				g.shootMissile(i, 0, input)
+			} else {
+				input.SendTo(0, &pb.ShootMissile{
+					Owner: *NetworkIdKey.Get(i),
+				})
 			}
+
+			ShipControlKey.Get(i).FireCoolDown = weaponSpecs[pb.WeaponKind_MISSILE].coolDown
+			// ShipControlKey.Get(i).FireCoolDown = 5
 		}
 	}
+}
 
-	{
-		i := g.E.NewIter()
-		i.Require(RotKey)
-		i.Require(SpinKey)
-
-		for i.Next() {
-			*i.Rot() += *i.Spin() * input.Dt
+// steerShip applies the movement controls of the ship the iter is pointing at.
+func steerShip(i *Iter, dt float32) {
+	const rotationForSpeed = 5
+	const rotationAgainstSpeed = 10
+	const forwardSpeed = 4
+	// Reverse thrust is weaker, for braking rather than flying backwards.
+	const reverseSpeed = 2
+
+	spinDesire := float32(0)
+	if ShipControlKey.Get(i).Left {
+		spinDesire++
+	}
+	if ShipControlKey.Get(i).Right {
+		spinDesire--
+	}
+	if !ShipControlKey.Get(i).Left && !ShipControlKey.Get(i).Right {
+		s := *SpinKey.Get(i)
+		if s < -0.5 {
+			spinDesire += 0.1
+		} else if s > 0.5 {
+			spinDesire -= 0.1
 		}
 	}
 
-	{
-		i := g.E.NewIter()
-		i.Require(BoundLocationKey)
-		i.Require(PosKey)
-		i.Require(MomentumKey)
-
-		for i.Next() {
-			l := i.Pos().Length()
-			if l > 50 {
-				scale := 50 / l
-				*i.Pos() = i.Pos().Scale(scale)
-				// Calculate the momentum in the direction of the invisible wall, and
-				// cancel it out.
-				i.Momentum().AddEqual(i.Pos().Normalize().Scale((*i.Pos()).Normalize().Scale(-1).Dot(*i.Momentum())))
-			}
-		}
+	// Game feel: Stopping spin is easier than starting it.
+	if (spinDesire < 0) == (*SpinKey.Get(i) < 0) {
+		spinDesire *= rotationForSpeed
+	} else {
+		spinDesire *= rotationAgainstSpeed
 	}
 
-	{
-		// Force of gravity = gravconst * mass1 * mass2 / (distance)^2
+	*SpinKey.Get(i) += spinDesire * dt
 
-		// Update value = Dt * const * normalized direction vector / (distance)^2
+	if ShipControlKey.Get(i).Up {
+		dx := float32(math.Cos(float64(*RotKey.Get(i)))) * forwardSpeed * dt
+		dy := float32(math.Sin(float64(*RotKey.Get(i)))) * forwardSpeed * dt
+
+		(*MomentumKey.Get(i))[0] += dx
+		(*MomentumKey.Get(i))[1] += dy
+	}
 
-		// = Dt * const * (-1 * Pos / Pos.Lenght) / (Pos.Length) ^ 2
-		// = Dt * const * -1 * Pos / Pos.Length ^ 3
-		// = Pos.Scale(Dt * const * -1 / Pos.Length ^ 3)
+	if ShipControlKey.Get(i).Down {
+		MomentumKey.Get(i).AddEqual(Vec2FromRadians(*RotKey.Get(i)).Scale(-reverseSpeed * dt))
+	}
+}
 
-		// Pos.Length = (x*x + y*y) ^ 1/2
-		// sqrt then cube will probably be faster than taking to the power of 1.5?
+func (g *Game) spawnShipParticles(input *Input) {
+	i := g.E.NewIter()
+	i.Require(PosKey)
+	i.Require(RotKey)
+	i.Require(ShipControlKey)
+	i.Require(MomentumKey)
+
+	ip := g.E.NewIter()
+	ip.Require(PosKey)
+	ip.Require(PointRenderKey)
+	ip.Require(MomentumKey)
+	ip.Require(TimedDestroyKey)
+	ip.Require(ParticleSunDeleteKey)
+
+	for i.Next() {
+		const pushFactor = 5
+		emitPoint := PosKey.Get(i).Sub(Vec2FromRadians(*RotKey.Get(i)).Scale(0.4))
+		nosePoint := PosKey.Get(i).Add(Vec2FromRadians(*RotKey.Get(i)).Scale(0.4))
+
+		if ShipControlKey.Get(i).Up {
+			ip.New()
+			*PosKey.Get(ip) = emitPoint
+
+			angleOut := *RotKey.Get(i) + math.Pi + (g.Rand.Float32()-0.5)/3
+			*MomentumKey.Get(ip) = MomentumKey.Get(i).Add(Vec2FromRadians(angleOut).Scale(pushFactor))
+			*TimedDestroyKey.Get(ip) = g.Rand.Float32()*2 + 1
+		}
+		if ShipControlKey.Get(i).Left {
+			ip.New()
+			*PosKey.Get(ip) = emitPoint
 
-		i := g.E.NewIter()
-		i.Require(PosKey)
-		i.Require(AffectedByGravityKey)
-		i.Require(MomentumKey)
+			angleOut := *RotKey.Get(i) + math.Pi/2 + (g.Rand.Float32()-0.5)/3
+			*MomentumKey.Get(ip) = MomentumKey.Get(i).Add(Vec2FromRadians(angleOut).Scale(pushFactor))
+			*TimedDestroyKey.Get(ip) = g.Rand.Float32()*2 + 1
+		}
+		if ShipControlKey.Get(i).Right {
+			ip.New()
+			*PosKey.Get(ip) = emitPoint
 
-		const gravityStrength = 200
+			angleOut := *RotKey.Get(i) + math.Pi*3/2 + (g.Rand.Float32()-0.5)/3
+			*MomentumKey.Get(ip) = MomentumKey.Get(i).Add(Vec2FromRadians(angleOut).Scale(pushFactor))
+			*TimedDestroyKey.Get(ip) = g.Rand.Float32()*2 + 1
+		}
+		if ShipControlKey.Get(i).Down {
+			ip.New()
+			*PosKey.Get(ip) = nosePoint
 
-		for i.Next() {
-			length := i.Pos().Length()
-			lengthCubed := length * length * length
-			i.Momentum().AddEqual(i.Pos().Scale(-1 * gravityStrength * input.Dt / lengthCubed))
+			angleOut := *RotKey.Get(i) + (g.Rand.Float32()-0.5)/3
+			*MomentumKey.Get(ip) = MomentumKey.Get(i).Add(Vec2FromRadians(angleOut).Scale(pushFactor))
+			*TimedDestroyKey.Get(ip) = g.Rand.Float32()*2 + 1
 		}
 	}
+}
 
-	{
-		i := g.E.NewIter()
-		i.Require(PosKey)
-		i.Require(MomentumKey)
+func (g *Game) missileThrust(input *Input) {
+	i := g.E.NewIter()
+	i.Require(RotKey)
+	i.Require(MomentumKey)
+	i.Require(MissileDetailsKey)
 
-		for i.Next() {
-			i.Pos().AddEqual(i.Momentum().Scale(input.Dt))
-		}
+	for i.Next() {
+		const pushFactor = 10
+		MomentumKey.Get(i).AddEqual(Vec2FromRadians(*RotKey.Get(i)).Scale(pushFactor * input.Dt))
 	}
+}
 
-	{
-		i := g.E.NewIter()
-		i.Require(FrameEndDeleteKey)
-		for i.Next() {
-			i.Remove()
+func (g *Game) spawnMissileParticles(input *Input) {
+	i := g.E.NewIter()
+	i.Require(RotKey)
+	i.Require(MissileDetailsKey)
+	i.Require(PosKey)
+
+	ip := g.E.NewIter()
+	ip.Require(PosKey)
+	ip.Require(PointRenderKey)
+	ip.Require(MomentumKey)
+	ip.Require(TimedDestroyKey)
+	ip.Require(ParticleSunDeleteKey)
+
+	for i.Next() {
+		const pushFactor = 5
+
+		for j := 0; j < 4; j++ {
+			ip.New()
+			*PosKey.Get(ip) = *PosKey.Get(i)
+
+			angleOut := *RotKey.Get(i) + math.Pi + (g.Rand.Float32()-0.5)/2
+			*MomentumKey.Get(ip) = MomentumKey.Get(i).Add(Vec2FromRadians(angleOut).Scale(pushFactor))
+			PosKey.Get(ip).AddEqual(MomentumKey.Get(ip).Scale(float32(j) * input.Dt / 4))
+			*TimedDestroyKey.Get(ip) = g.Rand.Float32()*2 + 1
 		}
 	}
+}
 
-	{
-		posTracks := &pb.PosTracks{}
+func (g *Game) spin(input *Input) {
+	i := g.E.NewIter()
+	i.Require(RotKey)
+	i.Require(SpinKey)
 
-		i := g.E.NewIter()
-		i.Require(PosKey)
-		i.Require(NetworkTransmitKey)
-		i.Require(NetworkIdKey)
+	for i.Next() {
+		*RotKey.Get(i) += *SpinKey.Get(i) * input.Dt
+	}
+}
 
-		for i.Next() {
-			posTracks.Nid = append(posTracks.Nid, *i.NetworkId())
-			pos := *i.Pos()
-			posTracks.X = append(posTracks.X, pos[0])
-			posTracks.Y = append(posTracks.Y, pos[1])
-		}
+func (g *Game) boundLocation(input *Input) {
+	i := g.E.NewIter()
+	i.Require(BoundLocationKey)
+	i.Require(PosKey)
+	i.Require(MomentumKey)
 
-		input.BroadcastOthers(posTracks)
+	for i.Next() {
+		bound(i)
 	}
+}
 
-	{
-		rotTracks := &pb.RotTracks{}
+// bound keeps the entity the iter is pointing at inside the edge of the map.
+func bound(i *Iter) {
+	l := PosKey.Get(i).Length()
+	if l > 50 {
+		scale := 50 / l
+		*PosKey.Get(i) = PosKey.Get(i).Scale(scale)
+		// Calculate the momentum in the direction of the invisible wall, and
+		// cancel it out.
+		MomentumKey.Get(i).AddEqual(PosKey.Get(i).Normalize().Scale((*PosKey.Get(i)).Normalize().Scale(-1).Dot(*MomentumKey.Get(i))))
+	}
+}
 
-		i := g.E.NewIter()
-		i.Require(RotKey)
-		i.Require(NetworkTransmitKey)
-		i.Require(NetworkIdKey)
+func (g *Game) gravity(input *Input) {
+	// Force of gravity = gravconst * mass1 * mass2 / (distance)^2
 
-		for i.Next() {
-			rotTracks.Nid = append(rotTracks.Nid, *i.NetworkId())
-			rotTracks.R = append(rotTracks.R, *i.Rot())
-		}
+	// Update value = Dt * const * normalized direction vector / (distance)^2
 
-		input.BroadcastOthers(rotTracks)
-	}
+	// = Dt * const * (-1 * Pos / Pos.Lenght) / (Pos.Length) ^ 2
+	// = Dt * const * -1 * Pos / Pos.Length ^ 3
+	// = Pos.Scale(Dt * const * -1 / Pos.Length ^ 3)
 
-	{
-		momentumTracks := &pb.MomentumTracks{}
+	// Pos.Length = (x*x + y*y) ^ 1/2
+	// sqrt then cube will probably be faster than taking to the power of 1.5?
 
-		i := g.E.NewIter()
-		i.Require(MomentumKey)
-		i.Require(NetworkTransmitKey)
-		i.Require(NetworkIdKey)
+	i := g.E.NewIter()
+	i.Require(PosKey)
+	i.Require(AffectedByGravityKey)
+	i.Require(MomentumKey)
 
-		for i.Next() {
-			momentumTracks.Nid = append(momentumTracks.Nid, *i.NetworkId())
-			momentum := *i.Momentum()
-			momentumTracks.X = append(momentumTracks.X, momentum[0])
-			momentumTracks.Y = append(momentumTracks.Y, momentum[1])
-		}
-
-		input.BroadcastOthers(momentumTracks)
+	for i.Next() {
+		pull(i, input.Dt)
 	}
+}
 
-	{
-		spinTracks := &pb.SpinTracks{}
+// pull applies dt of gravity to the entity the iter is pointing at.
+const gravityStrength = 200
 
-		i := g.E.NewIter()
-		i.Require(SpinKey)
-		i.Require(NetworkTransmitKey)
-		i.Require(NetworkIdKey)
+func pull(i *Iter, dt float32) {
+	length := PosKey.Get(i).Length()
+	lengthCubed := length * length * length
+	MomentumKey.Get(i).AddEqual(PosKey.Get(i).Scale(-1 * gravityStrength * dt / lengthCubed))
+}
 
-		for i.Next() {
-			spinTracks.Nid = append(spinTracks.Nid, *i.NetworkId())
-			spinTracks.S = append(spinTracks.S, *i.Spin())
-		}
+func (g *Game) integrate(input *Input) {
+	i := g.E.NewIter()
+	i.Require(PosKey)
+	i.Require(MomentumKey)
 
-		input.BroadcastOthers(spinTracks)
+	for i.Next() {
+		PosKey.Get(i).AddEqual(MomentumKey.Get(i).Scale(input.Dt))
 	}
+}
+
+func (g *Game) frameEndDelete(input *Input) {
+	i := g.E.NewIter()
+	i.Require(FrameEndDeleteKey)
+	for i.Next() {
+		i.Remove()
+	}
+}
+
+func (g *Game) netTransmit(input *Input) {
+	g.transmitTracks(input)
 
 	{
 		i := g.E.NewIter()
@@ -1061,15 +1439,49 @@ func (g *Game) Step(input *Input) {
 		for i.Next() {
 			shipControlTrack := &pb.ShipControlTrack{}
 
-			shipControlTrack.Nid = *i.NetworkId()
-			sc := i.ShipControl()
+			shipControlTrack.Nid = *NetworkIdKey.Get(i)
+			sc := ShipControlKey.Get(i)
 			shipControlTrack.Up = sc.Up
+			shipControlTrack.Down = sc.Down
 			shipControlTrack.Left = sc.Left
 			shipControlTrack.Right = sc.Right
+			// Only set by the host of server authoritative games, so clients know
+			// which of their controls it has applied.
+			shipControlTrack.Seq = sc.Seq
 
 			input.BroadcastOthers(shipControlTrack)
 		}
 	}
+
+	if g.ServerAuthoritative && !input.IsHost {
+		g.serverClock.advance(input.Dt)
+	}
+
+	{
+		i := g.E.NewIter()
+		i.Require(ShipControlKey)
+		i.Require(PredictedKey)
+		i.Require(NetworkIdKey)
+
+		for i.Next() {
+			sc := ShipControlKey.Get(i)
+			sc.Seq++
+			g.recordInput(*sc, input.Dt)
+
+			input.SendTo(0, &pb.ShipControlTrack{
+				Nid:       *NetworkIdKey.Get(i),
+				Up:        sc.Up,
+				Down:      sc.Down,
+				Left:      sc.Left,
+				Right:     sc.Right,
+				Fire:      sc.Fire,
+				Secondary: sc.Secondary,
+				Weapon:    WeaponKey.Get(i).Kind,
+				Seq:       sc.Seq,
+				Tick:      g.viewTick(),
+			})
+		}
+	}
 }
 
 // func spawnSpaceship(i *Iter) {
@@ -1087,7 +1499,7 @@ func (g *Game) Step(input *Input) {
 // 	i.Require(CanExplodeKey)
 // 	i.New()
 
-// 	*i.Sprite() = SpriteShip
+// 	*SpriteKey.Get(i) = SpriteShip
 // }
 
 // func spawnExplosion(i *Iter) {
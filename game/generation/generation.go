@@ -17,111 +17,258 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"text/template"
+	"unicode"
 )
 
-var components = map[string]string{
-	// "ExplosionDetails": "ExplosionDetails",
-	"Lookup":         "Lookup",
-	"MissileDetails": "MissileDetails",
-	"Momentum":       "Vec2",
-	"NetworkId":      "uint64",
-	"Pos":            "Vec2",
-	"Rot":            "float32",
-	"ShipControl":    "ShipControl",
-	// "SpawnEvent":       "SpawnType",
-	"Spin":         "float32",
-	"Sprite":       "Sprite",
-	"TimedDestroy": "float32",
-	"TimedExplode": "float32",
-
-	"AffectedByGravity": "",
-	"BoundLocation":     "",
-	"CanExplode":        "",
-	"FrameEndDelete":    "",
-	"KeepInCamera":      "",
-	"NetworkReceive":    "",
-	"NetworkTransmit":   "",
-	"ParticleSunDelete": "",
-	"PointRender":       "",
-}
-
-var typeLiterals = map[string]string{
-	// "ExplosionDetails": "ExplosionDetails{Initialized: false}",
-	"float32":   "0",
-	"uint64":    "0",
-	"Lookup":    "<Lookup is special, this should be never invoked>",
-	"SpawnType": "0",
-	"Sprite":    "SpriteUnset",
+// The schema is read from components.json.  Each component has a name and the
+// Go type it stores, or no type if it is only a tag.  A replicated component is
+// sent by whoever has authority over the entity to everyone else, in the Tracks
+// memo.  Its track is its field number in that memo, so must never be changed
+// or reused.  Replicated components can also be interpolated, which smooths
+// them between tracks on the receiver rather than snapping to the latest one,
+// and quantized, which sends them as integers of the given number of bits
+// spread between min and max.  With wrap, values outside that range are
+// wrapped back into it (eg, angles) instead of being clamped.
+type schema struct {
+	Components []*component `json:"components"`
 }
 
+type component struct {
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	Replicated   bool      `json:"replicated"`
+	Track        int       `json:"track"`
+	Interpolated bool      `json:"interpolated"`
+	Quantize     *quantize `json:"quantize"`
+}
+
+type quantize struct {
+	Bits int     `json:"bits"`
+	Min  float32 `json:"min"`
+	Max  float32 `json:"max"`
+	Wrap bool    `json:"wrap"`
+}
+
+// Replicated values are sent as one repeated field per float in the type.
+var trackFields = map[string][]string{
+	"Vec2":    {"x", "y"},
+	"float32": {"value"},
+}
+
+// lerps are the functions which interpolate between two values of each type.
+var lerps = map[string]string{
+	"Vec2":    "lerpVec2",
+	"float32": "lerpFloat32",
+}
+
+// tickField is the field number of the tick in the Tracks memo, so track
+// numbers must be below it.
+const tickField = 100
+
 func main() {
 	fmt.Println("Starting generation")
 
-	t := template.Must(template.ParseFiles("components.gotemplate"))
-	f, err := os.Create("../components.go")
+	s := readSchema("components.json")
+	info := getInfo(s)
+
+	execute("components.gotemplate", "../components.go", info)
+	execute("tracks.gotemplate", "../tracks.go", info)
+	execute("tracks.prototemplate", "../pb/tracks.proto", info)
+
+	fmt.Println("Generation Complete")
+}
+
+func readSchema(path string) *schema {
+	f, err := os.Open(path)
 	if err != nil {
 		panic(err)
 	}
 	defer f.Close()
-	err = t.Execute(f, getInfo())
+
+	s := &schema{}
+	d := json.NewDecoder(f)
+	d.DisallowUnknownFields()
+	err = d.Decode(s)
 	if err != nil {
 		panic(err)
 	}
-	fmt.Println("Generation Complete")
+	return s
 }
 
-type compType struct {
-	Name                    string
-	TrueType                string
-	TypeLiteral             string
-	GenerateTypeDeclaration bool
-	ReturnPointer           bool
+func execute(templatePath, outputPath string, info *Info) {
+	t := template.Must(template.New(templatePath).Funcs(funcs).ParseFiles(templatePath))
+	f, err := os.Create(outputPath)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	err = t.Execute(f, info)
+	if err != nil {
+		panic(err)
+	}
 }
 
 type Info struct {
-	CompTypes map[string]*compType
-	Comps     map[string]*compType
-}
+	Comps []*component
+	Tags  []*component
+
+	// Replicated is ordered by track number.
+	Replicated   []*component
+	Interpolated []*component
 
-func getInfo() *Info {
-	// Known types start with those defined in the file because they don't fit the
-	// standard pattern.
+	TickField int
+}
 
+func getInfo(s *schema) *Info {
 	i := &Info{
-		CompTypes: make(map[string]*compType),
-		Comps:     make(map[string]*compType),
+		TickField: tickField,
 	}
 
-	for k, v := range components {
-		if v == "" {
-			i.Comps[k] = nil
-			continue
+	names := make(map[string]bool)
+	tracks := make(map[int]string)
+
+	for _, c := range s.Components {
+		if names[c.Name] {
+			panic("Component declared twice: " + c.Name)
 		}
-		if _, ok := i.CompTypes[v]; !ok {
-			c := &compType{
-				Name:                    "comp_" + v,
-				TrueType:                v,
-				GenerateTypeDeclaration: true,
-				ReturnPointer:           true,
+		names[c.Name] = true
+
+		if c.Type == "" {
+			if c.Replicated || c.Interpolated || c.Quantize != nil {
+				panic("Tag can't have network settings: " + c.Name)
 			}
+			i.Tags = append(i.Tags, c)
+			continue
+		}
+		i.Comps = append(i.Comps, c)
 
-			if literal, ok := typeLiterals[v]; ok {
-				c.TypeLiteral = literal
-			} else {
-				c.TypeLiteral = v + "{}"
+		if !c.Replicated {
+			if c.Track != 0 || c.Interpolated || c.Quantize != nil {
+				panic("Network settings on component which isn't replicated: " + c.Name)
 			}
+			continue
+		}
 
-			i.CompTypes[v] = c
+		if _, ok := trackFields[c.Type]; !ok {
+			panic(fmt.Sprintf("Component %s can't be replicated, type %s isn't supported", c.Name, c.Type))
+		}
+		if c.Track <= 0 || c.Track >= tickField {
+			panic(fmt.Sprintf("Replicated component %s needs a track number between 1 and %d", c.Name, tickField-1))
+		}
+		if other, ok := tracks[c.Track]; ok {
+			panic(fmt.Sprintf("Components %s and %s have the same track number", other, c.Name))
+		}
+		tracks[c.Track] = c.Name
+		if q := c.Quantize; q != nil {
+			// Values are float32, so more than 24 bits would be meaningless.
+			if q.Bits < 1 || q.Bits > 24 || q.Max <= q.Min {
+				panic("Invalid quantize settings for " + c.Name)
+			}
 		}
 
-		i.Comps[k] = i.CompTypes[v]
+		i.Replicated = append(i.Replicated, c)
+		if c.Interpolated {
+			i.Interpolated = append(i.Interpolated, c)
+		}
 	}
 
-	i.CompTypes["Lookup"].GenerateTypeDeclaration = false
-	i.CompTypes["Lookup"].ReturnPointer = false
+	sort.Slice(i.Replicated, func(a, b int) bool {
+		return i.Replicated[a].Track < i.Replicated[b].Track
+	})
 
 	return i
 }
+
+func (c *component) Fields() []string {
+	return trackFields[c.Type]
+}
+
+func (c *component) ProtoType() string {
+	if c.Quantize != nil {
+		return "uint32"
+	}
+	return "float"
+}
+
+// Encode returns the expression for sending the value v of this component's
+// track.
+func (c *component) Encode(v string) string {
+	if c.Quantize == nil {
+		return v
+	}
+	return fmt.Sprintf("%s.encode(%s)", c.Quantization(), v)
+}
+
+// Decode is the inverse of Encode.
+func (c *component) Decode(v string) string {
+	if c.Quantize == nil {
+		return v
+	}
+	return fmt.Sprintf("%s.decode(%s)", c.Quantization(), v)
+}
+
+// Lerp returns the function for interpolating between two values of the
+// component.  Wrapped values go the shorter way around.
+func (c *component) Lerp() string {
+	if c.Quantize != nil && c.Quantize.Wrap && c.Type == "float32" {
+		return c.Quantization() + ".lerp"
+	}
+	return lerps[c.Type]
+}
+
+// Quantization is the name of the variable holding the component's quantize
+// settings.
+func (c *component) Quantization() string {
+	return lowerFirst(c.Name) + "Quantization"
+}
+
+var funcs = template.FuncMap{
+	"snake":      snake,
+	"camel":      camel,
+	"lowerFirst": lowerFirst,
+	"add": func(a, b int) int {
+		return a + b
+	},
+}
+
+func snake(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// camel converts a proto field name to the name of its generated Go field.
+func camel(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"sort"
+)
+
+// Tracks arrive unevenly, so rather than snapping NetworkReceive entities to
+// the latest one, rendered games keep the recent tracks of their interpolated
+// components, keyed by the tick the sender sent them on.  The entities are
+// shown InterpolationDelay behind the sender, between the two tracks either
+// side of that time.  If tracks stop arriving for longer than the delay, the
+// entity is left to the game's physics until they resume.
+
+// DefaultInterpolationDelay is the InterpolationDelay of new games, in seconds.
+const DefaultInterpolationDelay = 0.1
+
+// maxSamples bounds each buffer, in case the clock falls far behind.
+const maxSamples = 32
+
+// maxClockLead is how many ticks an entity's clock may run past the newest
+// track, before it waits for the sender to catch up.
+const maxClockLead = 30
+
+type sample[T any] struct {
+	tick uint64
+	v    T
+}
+
+// interpolationBuffer holds the tracks of one component, ordered by tick.
+type interpolationBuffer[T any] struct {
+	samples []sample[T]
+}
+
+func (b *interpolationBuffer[T]) add(tick uint64, v T) {
+	n := len(b.samples)
+	j := sort.Search(n, func(k int) bool {
+		return b.samples[k].tick >= tick
+	})
+	if j < n && b.samples[j].tick == tick {
+		b.samples[j].v = v
+		return
+	}
+
+	b.samples = append(b.samples, sample[T]{})
+	copy(b.samples[j+1:], b.samples[j:])
+	b.samples[j] = sample[T]{tick, v}
+
+	if len(b.samples) > maxSamples {
+		b.samples = b.samples[1:]
+	}
+}
+
+// at returns the value at tick t.  ok is false if there is no track at or after
+// t to interpolate towards.
+func (b *interpolationBuffer[T]) at(t float64, lerp func(a, b T, f float32) T) (v T, ok bool) {
+	// Only the last track before t is needed, older ones are dropped.
+	for len(b.samples) >= 2 && float64(b.samples[1].tick) <= t {
+		b.samples = b.samples[1:]
+	}
+
+	if len(b.samples) == 0 {
+		return v, false
+	}
+	first := b.samples[0]
+	if t <= float64(first.tick) {
+		return first.v, true
+	}
+	if len(b.samples) == 1 {
+		return v, false
+	}
+	second := b.samples[1]
+	f := (t - float64(first.tick)) / float64(second.tick-first.tick)
+	return lerp(first.v, second.v, float32(f)), true
+}
+
+// interpolationClock estimates which tick the sender of an entity's tracks is
+// on.  Each entity has its own, as in client authoritative games each client
+// sends its own ship, counting its own ticks.
+type interpolationClock struct {
+	clock  float64
+	newest uint64
+}
+
+func (c *interpolationClock) received(tick uint64) {
+	if tick > c.newest {
+		c.newest = tick
+	}
+	// Tracks can arrive late, but never early, so the earliest arriving one is
+	// the best guess of the sender's tick.
+	if c.clock < float64(c.newest) {
+		c.clock = float64(c.newest)
+	}
+}
+
+func (c *interpolationClock) advance(dt float32) {
+	c.clock += float64(dt / TickDt)
+	if lead := float64(c.newest + maxClockLead); c.clock > lead {
+		c.clock = lead
+	}
+}
+
+// interpolation returns the buffers of the entity the iter is pointing at,
+// having received a track sent on tick.
+func (g *Game) interpolation(i *Iter, tick uint64) *interpolated {
+	id := LookupKey.Get(i)
+	in, ok := g.interpolations[id]
+	if !ok {
+		in = &interpolated{}
+		g.interpolations[id] = in
+	}
+	in.received(tick)
+	return in
+}
+
+func (g *Game) interpolate(input *Input) {
+	delay := float64(g.InterpolationDelay / TickDt)
+
+	i := g.E.NewIter()
+	i.Require(NetworkReceiveKey)
+	i.Require(LookupKey)
+	i.Any(interpolatedKeys...)
+	for i.Next() {
+		in, ok := g.interpolations[LookupKey.Get(i)]
+		if !ok {
+			continue
+		}
+		in.advance(input.Dt)
+		in.apply(i, in.clock-delay)
+	}
+
+	for id := range g.interpolations {
+		if !g.E.Alive(id) {
+			delete(g.interpolations, id)
+		}
+	}
+}
+
+func lerpVec2(a, b Vec2, f float32) Vec2 {
+	return a.Add(b.Sub(a).Scale(f))
+}
+
+func lerpFloat32(a, b float32, f float32) float32 {
+	return a + (b-a)*f
+}
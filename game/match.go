@@ -0,0 +1,129 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"github.com/laremere/space-agon/game/pb"
+)
+
+// A host with Rules runs a match.  It waits until enough players have joined,
+// counts down, and then plays until the game mode says it has been won or the
+// time limit runs out.  If players leave during the countdown, leaving too few,
+// it goes back to waiting.  If the mode says it's tied when time runs out, the
+// match goes to sudden death until it isn't.  Kills only count while the match
+// is live, so the ships flown while waiting for players are practice.  Once
+// the match has ended no more ships are spawned.
+//
+// The host sends a MatchState whenever the phase changes, and clients count
+// down the time left themselves.
+
+// Match is the current phase of the match, and the seconds until it ends.
+type Match struct {
+	Phase    pb.MatchState_Phase
+	TimeLeft float32
+}
+
+// DefaultMatchRules are the rules dedicated servers use unless configured
+// otherwise.
+func DefaultMatchRules() *pb.MatchRules {
+	return &pb.MatchRules{
+		MinPlayers: 2,
+		Countdown:  10,
+		TimeLimit:  5 * 60,
+		KillTarget: 20,
+	}
+}
+
+// Ended returns whether the match is over.
+func (m *Match) Ended() bool {
+	return m.Phase == pb.MatchState_ENDED
+}
+
+// scoring returns whether kills and deaths count.  Games without rules always
+// keep score.
+func (g *Game) scoring() bool {
+	if g.Rules == nil {
+		return true
+	}
+	switch g.Match.Phase {
+	case pb.MatchState_LIVE, pb.MatchState_SUDDEN_DEATH:
+		return true
+	}
+	return false
+}
+
+// canSpawn returns whether players may spawn ships.
+func (g *Game) canSpawn() bool {
+	return g.Rules == nil || !g.Match.Ended()
+}
+
+func (g *Game) runMatch(input *Input) {
+	m := &g.Match
+	if m.TimeLeft > 0 {
+		m.TimeLeft -= input.Dt
+		if m.TimeLeft < 0 {
+			m.TimeLeft = 0
+		}
+	}
+
+	if !input.IsHost {
+		return
+	}
+	g.Mode.OnTick(g, input)
+	if g.Rules == nil {
+		return
+	}
+
+	switch m.Phase {
+	case pb.MatchState_WAITING_FOR_PLAYERS:
+		if g.players() >= int(g.Rules.MinPlayers) {
+			g.setPhase(pb.MatchState_COUNTDOWN, g.Rules.Countdown, input)
+		}
+
+	case pb.MatchState_COUNTDOWN:
+		if g.players() < int(g.Rules.MinPlayers) {
+			g.setPhase(pb.MatchState_WAITING_FOR_PLAYERS, 0, input)
+		} else if m.TimeLeft <= 0 {
+			g.setPhase(pb.MatchState_LIVE, g.Rules.TimeLimit, input)
+		}
+
+	case pb.MatchState_LIVE:
+		if g.Mode.IsOver(g) {
+			g.setPhase(pb.MatchState_ENDED, 0, input)
+		} else if g.Rules.TimeLimit > 0 && m.TimeLeft <= 0 {
+			if g.Mode.Tied(g) {
+				g.setPhase(pb.MatchState_SUDDEN_DEATH, 0, input)
+			} else {
+				g.setPhase(pb.MatchState_ENDED, 0, input)
+			}
+		}
+
+	case pb.MatchState_SUDDEN_DEATH:
+		if g.Mode.IsOver(g) || !g.Mode.Tied(g) {
+			g.setPhase(pb.MatchState_ENDED, 0, input)
+		}
+	}
+}
+
+func (g *Game) setPhase(phase pb.MatchState_Phase, timeLeft float32, input *Input) {
+	g.Match = Match{
+		Phase:    phase,
+		TimeLeft: timeLeft,
+	}
+	input.BroadcastAll(&pb.MatchState{
+		Phase:    phase,
+		TimeLeft: timeLeft,
+	})
+}
@@ -0,0 +1,227 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"github.com/laremere/space-agon/game/pb"
+)
+
+// A GameMode decides who may spawn, how kills are scored, and when a match is
+// won.  Its hooks are only called on the host.  Modes keep everything they
+// need on the scoreboard, so that it is saved in snapshots along with the rest
+// of the game.
+type GameMode interface {
+	// Name is what the mode is chosen by, see LookupGameMode.
+	Name() string
+	// OnPlayerRegistered is called when a player asks for a ship, after they
+	// have been put on the scoreboard, and returns whether they may have one.
+	// The ship is put on the team the player has on the scoreboard.
+	OnPlayerRegistered(g *Game, register *pb.RegisterPlayer, input *Input) bool
+	// OnKill is called when the ship of victim is destroyed while kills count.
+	// killer is 0 if no player caused it.
+	OnKill(g *Game, victim int64, killer int64, input *Input)
+	// OnTick is called every tick.
+	OnTick(g *Game, input *Input)
+	// IsOver returns whether the match has been won.
+	IsOver(g *Game) bool
+	// Tied returns whether the match would be drawn if it ended now, in which
+	// case it goes to sudden death when time runs out.
+	Tied(g *Game) bool
+}
+
+// GameModes are all the modes, the first is the default.
+var GameModes = []GameMode{
+	FreeForAll{},
+	TeamDeathmatch{},
+	LastShipStanding{},
+}
+
+// LookupGameMode returns the mode with the name.
+func LookupGameMode(name string) (GameMode, bool) {
+	for _, mode := range GameModes {
+		if mode.Name() == name {
+			return mode, true
+		}
+	}
+	return nil, false
+}
+
+// FreeForAll is won by the first player to reach the kill target, or the
+// player with the most points when time runs out.
+type FreeForAll struct{}
+
+func (FreeForAll) Name() string {
+	return "ffa"
+}
+
+func (FreeForAll) OnPlayerRegistered(g *Game, register *pb.RegisterPlayer, input *Input) bool {
+	return true
+}
+
+func (FreeForAll) OnKill(g *Game, victim int64, killer int64, input *Input) {
+	g.scoreKill(victim, killer)
+}
+
+func (FreeForAll) OnTick(g *Game, input *Input) {
+}
+
+func (FreeForAll) IsOver(g *Game) bool {
+	if g.Rules.KillTarget <= 0 {
+		return false
+	}
+	for _, s := range g.Scoreboard.Scores {
+		if s.Kills >= g.Rules.KillTarget {
+			return true
+		}
+	}
+	return false
+}
+
+func (FreeForAll) Tied(g *Game) bool {
+	points := make(map[int64]int32)
+	for _, s := range g.Scoreboard.Scores {
+		points[s.Cid] = Points(s)
+	}
+	return leadTied(points)
+}
+
+// TeamDeathmatch puts players on two teams, and is won by the first team to
+// reach the kill target between them.  Killing a teammate counts as a suicide.
+type TeamDeathmatch struct{}
+
+func (TeamDeathmatch) Name() string {
+	return "tdm"
+}
+
+// OnPlayerRegistered puts new players on the team they asked for, unless it
+// already has more players than the other, in which case they go on the other.
+func (TeamDeathmatch) OnPlayerRegistered(g *Game, register *pb.RegisterPlayer, input *Input) bool {
+	s := g.score(register.Cid)
+	if s.Team != 0 {
+		return true
+	}
+
+	var players [3]int
+	for _, other := range g.Scoreboard.Scores {
+		if !other.Departed {
+			players[other.Team]++
+		}
+	}
+	switch {
+	case players[1] < players[2]:
+		s.Team = 1
+	case players[2] < players[1]:
+		s.Team = 2
+	case register.Team == 2:
+		s.Team = 2
+	default:
+		s.Team = 1
+	}
+	return true
+}
+
+func (TeamDeathmatch) OnKill(g *Game, victim int64, killer int64, input *Input) {
+	if killer != 0 && killer != victim && g.score(killer).Team == g.score(victim).Team {
+		g.score(victim).Deaths++
+		g.score(killer).Suicides++
+		return
+	}
+	g.scoreKill(victim, killer)
+}
+
+func (TeamDeathmatch) OnTick(g *Game, input *Input) {
+}
+
+func (TeamDeathmatch) IsOver(g *Game) bool {
+	if g.Rules.KillTarget <= 0 {
+		return false
+	}
+	kills := make(map[int32]int32)
+	for _, s := range g.Scoreboard.Scores {
+		kills[s.Team] += s.Kills
+		if kills[s.Team] >= g.Rules.KillTarget {
+			return true
+		}
+	}
+	return false
+}
+
+func (TeamDeathmatch) Tied(g *Game) bool {
+	return leadTied(TeamPoints(g.Scoreboard))
+}
+
+// LastShipStanding gives each player one ship once the match is live, and is
+// won by the last player with a ship left.  Players who leave are out.
+type LastShipStanding struct{}
+
+func (LastShipStanding) Name() string {
+	return "lss"
+}
+
+// OnPlayerRegistered refuses players who have already been destroyed.  Deaths
+// only count once the match is live, so ships lost beforehand don't matter.
+func (LastShipStanding) OnPlayerRegistered(g *Game, register *pb.RegisterPlayer, input *Input) bool {
+	return g.score(register.Cid).Deaths == 0
+}
+
+func (LastShipStanding) OnKill(g *Game, victim int64, killer int64, input *Input) {
+	g.scoreKill(victim, killer)
+}
+
+func (LastShipStanding) OnTick(g *Game, input *Input) {
+}
+
+func (m LastShipStanding) IsOver(g *Game) bool {
+	return m.survivors(g) <= 1
+}
+
+func (m LastShipStanding) Tied(g *Game) bool {
+	return m.survivors(g) > 1
+}
+
+func (LastShipStanding) survivors(g *Game) int {
+	survivors := 0
+	for _, s := range g.Scoreboard.Scores {
+		if s.Deaths == 0 && !s.Departed {
+			survivors++
+		}
+	}
+	return survivors
+}
+
+// TeamPoints returns the total points of each team.
+func TeamPoints(scoreboard *pb.Scoreboard) map[int32]int32 {
+	points := make(map[int32]int32)
+	for _, s := range scoreboard.Scores {
+		points[s.Team] += Points(s)
+	}
+	return points
+}
+
+// leadTied returns whether more than one of the points is the best.
+func leadTied[K comparable](points map[K]int32) bool {
+	leaders := 0
+	var best int32
+	for _, p := range points {
+		switch {
+		case leaders == 0 || p > best:
+			leaders = 1
+			best = p
+		case p == best:
+			leaders++
+		}
+	}
+	return leaders > 1
+}
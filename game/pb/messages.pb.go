@@ -20,8 +20,77 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type WeaponKind int32
+
+const (
+	WeaponKind_MISSILE WeaponKind = 0
+	WeaponKind_LASER   WeaponKind = 1
+	WeaponKind_MINE    WeaponKind = 2
+	WeaponKind_HOMING  WeaponKind = 3
+)
+
+var WeaponKind_name = map[int32]string{
+	0: "MISSILE",
+	1: "LASER",
+	2: "MINE",
+	3: "HOMING",
+}
+
+var WeaponKind_value = map[string]int32{
+	"MISSILE": 0,
+	"LASER":   1,
+	"MINE":    2,
+	"HOMING":  3,
+}
+
+func (x WeaponKind) String() string {
+	return proto.EnumName(WeaponKind_name, int32(x))
+}
+
+func (WeaponKind) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{0}
+}
+
+type MatchState_Phase int32
+
+const (
+	MatchState_WAITING_FOR_PLAYERS MatchState_Phase = 0
+	MatchState_COUNTDOWN           MatchState_Phase = 1
+	MatchState_LIVE                MatchState_Phase = 2
+	// The time limit has passed with the lead tied, the next kill decides it.
+	MatchState_SUDDEN_DEATH MatchState_Phase = 3
+	MatchState_ENDED        MatchState_Phase = 4
+)
+
+var MatchState_Phase_name = map[int32]string{
+	0: "WAITING_FOR_PLAYERS",
+	1: "COUNTDOWN",
+	2: "LIVE",
+	3: "SUDDEN_DEATH",
+	4: "ENDED",
+}
+
+var MatchState_Phase_value = map[string]int32{
+	"WAITING_FOR_PLAYERS": 0,
+	"COUNTDOWN":           1,
+	"LIVE":                2,
+	"SUDDEN_DEATH":        3,
+	"ENDED":               4,
+}
+
+func (x MatchState_Phase) String() string {
+	return proto.EnumName(MatchState_Phase_name, int32(x))
+}
+
+func (MatchState_Phase) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{17, 0}
+}
+
 type ClientInitialize struct {
-	Cid                  int64    `protobuf:"varint,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	Cid int64 `protobuf:"varint,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// Whether the server simulates every ship, and clients only send it their
+	// ShipControlTracks.
+	ServerAuthoritative  bool     `protobuf:"varint,2,opt,name=server_authoritative,json=serverAuthoritative,proto3" json:"serverAuthoritative,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -59,6 +128,13 @@ func (m *ClientInitialize) GetCid() int64 {
 	return 0
 }
 
+func (m *ClientInitialize) GetServerAuthoritative() bool {
+	if m != nil {
+		return m.ServerAuthoritative
+	}
+	return false
+}
+
 type Memos struct {
 	Memos                []*Memo  `protobuf:"bytes,1,rep,name=memos,proto3" json:"memos,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -103,12 +179,10 @@ type Memo struct {
 	//	*Memo_To
 	//	*Memo_EveryoneBut
 	//	*Memo_Everyone
+	//	*Memo_Team
 	Recipient isMemo_Recipient `protobuf_oneof:"recipient"`
 	// Types that are valid to be assigned to Actual:
-	//	*Memo_PosTracks
-	//	*Memo_MomentumTracks
-	//	*Memo_RotTracks
-	//	*Memo_SpinTracks
+	//	*Memo_Tracks
 	//	*Memo_ShipControlTrack
 	//	*Memo_DestroyEvent
 	//	*Memo_ShootMissile
@@ -116,6 +190,13 @@ type Memo struct {
 	//	*Memo_SpawnExplosion
 	//	*Memo_SpawnShip
 	//	*Memo_RegisterPlayer
+	//	*Memo_DamageEvent
+	//	*Memo_Scoreboard
+	//	*Memo_MatchState
+	//	*Memo_FireWeapon
+	//	*Memo_LaserBeam
+	//	*Memo_SpawnMine
+	//	*Memo_PlayerLeft
 	Actual               isMemo_Actual `protobuf_oneof:"actual"`
 	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
 	XXX_unrecognized     []byte        `json:"-"`
@@ -163,12 +244,18 @@ type Memo_Everyone struct {
 	Everyone bool `protobuf:"varint,3,opt,name=everyone,proto3,oneof"`
 }
 
+type Memo_Team struct {
+	Team int32 `protobuf:"varint,4,opt,name=team,proto3,oneof"`
+}
+
 func (*Memo_To) isMemo_Recipient() {}
 
 func (*Memo_EveryoneBut) isMemo_Recipient() {}
 
 func (*Memo_Everyone) isMemo_Recipient() {}
 
+func (*Memo_Team) isMemo_Recipient() {}
+
 func (m *Memo) GetRecipient() isMemo_Recipient {
 	if m != nil {
 		return m.Recipient
@@ -197,24 +284,19 @@ func (m *Memo) GetEveryone() bool {
 	return false
 }
 
-type isMemo_Actual interface {
-	isMemo_Actual()
-}
-
-type Memo_PosTracks struct {
-	PosTracks *PosTracks `protobuf:"bytes,10,opt,name=pos_tracks,json=posTracks,proto3,oneof"`
-}
-
-type Memo_MomentumTracks struct {
-	MomentumTracks *MomentumTracks `protobuf:"bytes,11,opt,name=momentum_tracks,json=momentumTracks,proto3,oneof"`
+func (m *Memo) GetTeam() int32 {
+	if x, ok := m.GetRecipient().(*Memo_Team); ok {
+		return x.Team
+	}
+	return 0
 }
 
-type Memo_RotTracks struct {
-	RotTracks *RotTracks `protobuf:"bytes,12,opt,name=rot_tracks,json=rotTracks,proto3,oneof"`
+type isMemo_Actual interface {
+	isMemo_Actual()
 }
 
-type Memo_SpinTracks struct {
-	SpinTracks *SpinTracks `protobuf:"bytes,13,opt,name=spin_tracks,json=spinTracks,proto3,oneof"`
+type Memo_Tracks struct {
+	Tracks *Tracks `protobuf:"bytes,22,opt,name=tracks,proto3,oneof"`
 }
 
 type Memo_ShipControlTrack struct {
@@ -245,13 +327,35 @@ type Memo_RegisterPlayer struct {
 	RegisterPlayer *RegisterPlayer `protobuf:"bytes,21,opt,name=register_player,json=registerPlayer,proto3,oneof"`
 }
 
-func (*Memo_PosTracks) isMemo_Actual() {}
+type Memo_DamageEvent struct {
+	DamageEvent *DamageEvent `protobuf:"bytes,23,opt,name=damage_event,json=damageEvent,proto3,oneof"`
+}
 
-func (*Memo_MomentumTracks) isMemo_Actual() {}
+type Memo_Scoreboard struct {
+	Scoreboard *Scoreboard `protobuf:"bytes,24,opt,name=scoreboard,proto3,oneof"`
+}
 
-func (*Memo_RotTracks) isMemo_Actual() {}
+type Memo_MatchState struct {
+	MatchState *MatchState `protobuf:"bytes,25,opt,name=match_state,json=matchState,proto3,oneof"`
+}
 
-func (*Memo_SpinTracks) isMemo_Actual() {}
+type Memo_FireWeapon struct {
+	FireWeapon *FireWeapon `protobuf:"bytes,26,opt,name=fire_weapon,json=fireWeapon,proto3,oneof"`
+}
+
+type Memo_LaserBeam struct {
+	LaserBeam *LaserBeam `protobuf:"bytes,27,opt,name=laser_beam,json=laserBeam,proto3,oneof"`
+}
+
+type Memo_SpawnMine struct {
+	SpawnMine *SpawnMine `protobuf:"bytes,28,opt,name=spawn_mine,json=spawnMine,proto3,oneof"`
+}
+
+type Memo_PlayerLeft struct {
+	PlayerLeft *PlayerLeft `protobuf:"bytes,29,opt,name=player_left,json=playerLeft,proto3,oneof"`
+}
+
+func (*Memo_Tracks) isMemo_Actual() {}
 
 func (*Memo_ShipControlTrack) isMemo_Actual() {}
 
@@ -267,37 +371,30 @@ func (*Memo_SpawnShip) isMemo_Actual() {}
 
 func (*Memo_RegisterPlayer) isMemo_Actual() {}
 
-func (m *Memo) GetActual() isMemo_Actual {
-	if m != nil {
-		return m.Actual
-	}
-	return nil
-}
+func (*Memo_DamageEvent) isMemo_Actual() {}
 
-func (m *Memo) GetPosTracks() *PosTracks {
-	if x, ok := m.GetActual().(*Memo_PosTracks); ok {
-		return x.PosTracks
-	}
-	return nil
-}
+func (*Memo_Scoreboard) isMemo_Actual() {}
 
-func (m *Memo) GetMomentumTracks() *MomentumTracks {
-	if x, ok := m.GetActual().(*Memo_MomentumTracks); ok {
-		return x.MomentumTracks
-	}
-	return nil
-}
+func (*Memo_MatchState) isMemo_Actual() {}
+
+func (*Memo_FireWeapon) isMemo_Actual() {}
 
-func (m *Memo) GetRotTracks() *RotTracks {
-	if x, ok := m.GetActual().(*Memo_RotTracks); ok {
-		return x.RotTracks
+func (*Memo_LaserBeam) isMemo_Actual() {}
+
+func (*Memo_SpawnMine) isMemo_Actual() {}
+
+func (*Memo_PlayerLeft) isMemo_Actual() {}
+
+func (m *Memo) GetActual() isMemo_Actual {
+	if m != nil {
+		return m.Actual
 	}
 	return nil
 }
 
-func (m *Memo) GetSpinTracks() *SpinTracks {
-	if x, ok := m.GetActual().(*Memo_SpinTracks); ok {
-		return x.SpinTracks
+func (m *Memo) GetTracks() *Tracks {
+	if x, ok := m.GetActual().(*Memo_Tracks); ok {
+		return x.Tracks
 	}
 	return nil
 }
@@ -351,16 +448,63 @@ func (m *Memo) GetRegisterPlayer() *RegisterPlayer {
 	return nil
 }
 
+func (m *Memo) GetDamageEvent() *DamageEvent {
+	if x, ok := m.GetActual().(*Memo_DamageEvent); ok {
+		return x.DamageEvent
+	}
+	return nil
+}
+
+func (m *Memo) GetScoreboard() *Scoreboard {
+	if x, ok := m.GetActual().(*Memo_Scoreboard); ok {
+		return x.Scoreboard
+	}
+	return nil
+}
+
+func (m *Memo) GetMatchState() *MatchState {
+	if x, ok := m.GetActual().(*Memo_MatchState); ok {
+		return x.MatchState
+	}
+	return nil
+}
+
+func (m *Memo) GetFireWeapon() *FireWeapon {
+	if x, ok := m.GetActual().(*Memo_FireWeapon); ok {
+		return x.FireWeapon
+	}
+	return nil
+}
+
+func (m *Memo) GetLaserBeam() *LaserBeam {
+	if x, ok := m.GetActual().(*Memo_LaserBeam); ok {
+		return x.LaserBeam
+	}
+	return nil
+}
+
+func (m *Memo) GetSpawnMine() *SpawnMine {
+	if x, ok := m.GetActual().(*Memo_SpawnMine); ok {
+		return x.SpawnMine
+	}
+	return nil
+}
+
+func (m *Memo) GetPlayerLeft() *PlayerLeft {
+	if x, ok := m.GetActual().(*Memo_PlayerLeft); ok {
+		return x.PlayerLeft
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*Memo) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*Memo_To)(nil),
 		(*Memo_EveryoneBut)(nil),
 		(*Memo_Everyone)(nil),
-		(*Memo_PosTracks)(nil),
-		(*Memo_MomentumTracks)(nil),
-		(*Memo_RotTracks)(nil),
-		(*Memo_SpinTracks)(nil),
+		(*Memo_Team)(nil),
+		(*Memo_Tracks)(nil),
 		(*Memo_ShipControlTrack)(nil),
 		(*Memo_DestroyEvent)(nil),
 		(*Memo_ShootMissile)(nil),
@@ -368,274 +512,298 @@ func (*Memo) XXX_OneofWrappers() []interface{} {
 		(*Memo_SpawnExplosion)(nil),
 		(*Memo_SpawnShip)(nil),
 		(*Memo_RegisterPlayer)(nil),
+		(*Memo_DamageEvent)(nil),
+		(*Memo_Scoreboard)(nil),
+		(*Memo_MatchState)(nil),
+		(*Memo_FireWeapon)(nil),
+		(*Memo_LaserBeam)(nil),
+		(*Memo_SpawnMine)(nil),
+		(*Memo_PlayerLeft)(nil),
 	}
 }
 
-type PosTracks struct {
-	Nid                  []uint64  `protobuf:"varint,1,rep,packed,name=nid,proto3" json:"nid,omitempty"`
-	X                    []float32 `protobuf:"fixed32,2,rep,packed,name=x,proto3" json:"x,omitempty"`
-	Y                    []float32 `protobuf:"fixed32,3,rep,packed,name=y,proto3" json:"y,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+type ShipControlTrack struct {
+	Nid   uint64 `protobuf:"varint,1,opt,name=nid,proto3" json:"nid,omitempty"`
+	Up    bool   `protobuf:"varint,2,opt,name=up,proto3" json:"up,omitempty"`
+	Left  bool   `protobuf:"varint,3,opt,name=left,proto3" json:"left,omitempty"`
+	Right bool   `protobuf:"varint,4,opt,name=right,proto3" json:"right,omitempty"`
+	Fire  bool   `protobuf:"varint,5,opt,name=fire,proto3" json:"fire,omitempty"`
+	// Numbers the controls sent by a client for its own ship in server
+	// authoritative games.  The server echoes back the last one it applied.
+	Seq uint32 `protobuf:"varint,6,opt,name=seq,proto3" json:"seq,omitempty"`
+	// The host's tick which the client was showing when it sent the controls, for
+	// rollback.
+	Tick uint64 `protobuf:"varint,7,opt,name=tick,proto3" json:"tick,omitempty"`
+	Down bool   `protobuf:"varint,8,opt,name=down,proto3" json:"down,omitempty"`
+	// Secondary fire, and the weapon it uses, are only sent by clients in server
+	// authoritative games, like fire.
+	Secondary            bool       `protobuf:"varint,9,opt,name=secondary,proto3" json:"secondary,omitempty"`
+	Weapon               WeaponKind `protobuf:"varint,10,opt,name=weapon,proto3,enum=spaceagon.WeaponKind" json:"weapon,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
-func (m *PosTracks) Reset()         { *m = PosTracks{} }
-func (m *PosTracks) String() string { return proto.CompactTextString(m) }
-func (*PosTracks) ProtoMessage()    {}
-func (*PosTracks) Descriptor() ([]byte, []int) {
+func (m *ShipControlTrack) Reset()         { *m = ShipControlTrack{} }
+func (m *ShipControlTrack) String() string { return proto.CompactTextString(m) }
+func (*ShipControlTrack) ProtoMessage()    {}
+func (*ShipControlTrack) Descriptor() ([]byte, []int) {
 	return fileDescriptor_ae8bea4e98c5fae7, []int{3}
 }
 
-func (m *PosTracks) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_PosTracks.Unmarshal(m, b)
+func (m *ShipControlTrack) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ShipControlTrack.Unmarshal(m, b)
 }
-func (m *PosTracks) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_PosTracks.Marshal(b, m, deterministic)
+func (m *ShipControlTrack) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ShipControlTrack.Marshal(b, m, deterministic)
 }
-func (m *PosTracks) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_PosTracks.Merge(m, src)
+func (m *ShipControlTrack) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ShipControlTrack.Merge(m, src)
 }
-func (m *PosTracks) XXX_Size() int {
-	return xxx_messageInfo_PosTracks.Size(m)
+func (m *ShipControlTrack) XXX_Size() int {
+	return xxx_messageInfo_ShipControlTrack.Size(m)
 }
-func (m *PosTracks) XXX_DiscardUnknown() {
-	xxx_messageInfo_PosTracks.DiscardUnknown(m)
+func (m *ShipControlTrack) XXX_DiscardUnknown() {
+	xxx_messageInfo_ShipControlTrack.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_PosTracks proto.InternalMessageInfo
+var xxx_messageInfo_ShipControlTrack proto.InternalMessageInfo
 
-func (m *PosTracks) GetNid() []uint64 {
+func (m *ShipControlTrack) GetNid() uint64 {
 	if m != nil {
 		return m.Nid
 	}
-	return nil
+	return 0
 }
 
-func (m *PosTracks) GetX() []float32 {
+func (m *ShipControlTrack) GetUp() bool {
 	if m != nil {
-		return m.X
+		return m.Up
 	}
-	return nil
+	return false
 }
 
-func (m *PosTracks) GetY() []float32 {
+func (m *ShipControlTrack) GetLeft() bool {
 	if m != nil {
-		return m.Y
+		return m.Left
 	}
-	return nil
+	return false
 }
 
-type MomentumTracks struct {
-	Nid                  []uint64  `protobuf:"varint,1,rep,packed,name=nid,proto3" json:"nid,omitempty"`
-	X                    []float32 `protobuf:"fixed32,2,rep,packed,name=x,proto3" json:"x,omitempty"`
-	Y                    []float32 `protobuf:"fixed32,3,rep,packed,name=y,proto3" json:"y,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+func (m *ShipControlTrack) GetRight() bool {
+	if m != nil {
+		return m.Right
+	}
+	return false
 }
 
-func (m *MomentumTracks) Reset()         { *m = MomentumTracks{} }
-func (m *MomentumTracks) String() string { return proto.CompactTextString(m) }
-func (*MomentumTracks) ProtoMessage()    {}
-func (*MomentumTracks) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae8bea4e98c5fae7, []int{4}
+func (m *ShipControlTrack) GetFire() bool {
+	if m != nil {
+		return m.Fire
+	}
+	return false
 }
 
-func (m *MomentumTracks) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_MomentumTracks.Unmarshal(m, b)
-}
-func (m *MomentumTracks) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_MomentumTracks.Marshal(b, m, deterministic)
-}
-func (m *MomentumTracks) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_MomentumTracks.Merge(m, src)
-}
-func (m *MomentumTracks) XXX_Size() int {
-	return xxx_messageInfo_MomentumTracks.Size(m)
-}
-func (m *MomentumTracks) XXX_DiscardUnknown() {
-	xxx_messageInfo_MomentumTracks.DiscardUnknown(m)
+func (m *ShipControlTrack) GetSeq() uint32 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
 }
 
-var xxx_messageInfo_MomentumTracks proto.InternalMessageInfo
+func (m *ShipControlTrack) GetTick() uint64 {
+	if m != nil {
+		return m.Tick
+	}
+	return 0
+}
 
-func (m *MomentumTracks) GetNid() []uint64 {
+func (m *ShipControlTrack) GetDown() bool {
 	if m != nil {
-		return m.Nid
+		return m.Down
 	}
-	return nil
+	return false
 }
 
-func (m *MomentumTracks) GetX() []float32 {
+func (m *ShipControlTrack) GetSecondary() bool {
 	if m != nil {
-		return m.X
+		return m.Secondary
 	}
-	return nil
+	return false
 }
 
-func (m *MomentumTracks) GetY() []float32 {
+func (m *ShipControlTrack) GetWeapon() WeaponKind {
 	if m != nil {
-		return m.Y
+		return m.Weapon
 	}
-	return nil
+	return WeaponKind_MISSILE
 }
 
-type RotTracks struct {
-	Nid                  []uint64  `protobuf:"varint,1,rep,packed,name=nid,proto3" json:"nid,omitempty"`
-	R                    []float32 `protobuf:"fixed32,2,rep,packed,name=r,proto3" json:"r,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+// Sent to the host to fire a ship's secondary weapon.
+type FireWeapon struct {
+	Owner                uint64     `protobuf:"varint,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	Kind                 WeaponKind `protobuf:"varint,2,opt,name=kind,proto3,enum=spaceagon.WeaponKind" json:"kind,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
-func (m *RotTracks) Reset()         { *m = RotTracks{} }
-func (m *RotTracks) String() string { return proto.CompactTextString(m) }
-func (*RotTracks) ProtoMessage()    {}
-func (*RotTracks) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae8bea4e98c5fae7, []int{5}
+func (m *FireWeapon) Reset()         { *m = FireWeapon{} }
+func (m *FireWeapon) String() string { return proto.CompactTextString(m) }
+func (*FireWeapon) ProtoMessage()    {}
+func (*FireWeapon) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{4}
 }
 
-func (m *RotTracks) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_RotTracks.Unmarshal(m, b)
+func (m *FireWeapon) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FireWeapon.Unmarshal(m, b)
 }
-func (m *RotTracks) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_RotTracks.Marshal(b, m, deterministic)
+func (m *FireWeapon) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FireWeapon.Marshal(b, m, deterministic)
 }
-func (m *RotTracks) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_RotTracks.Merge(m, src)
+func (m *FireWeapon) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FireWeapon.Merge(m, src)
 }
-func (m *RotTracks) XXX_Size() int {
-	return xxx_messageInfo_RotTracks.Size(m)
+func (m *FireWeapon) XXX_Size() int {
+	return xxx_messageInfo_FireWeapon.Size(m)
 }
-func (m *RotTracks) XXX_DiscardUnknown() {
-	xxx_messageInfo_RotTracks.DiscardUnknown(m)
+func (m *FireWeapon) XXX_DiscardUnknown() {
+	xxx_messageInfo_FireWeapon.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_RotTracks proto.InternalMessageInfo
+var xxx_messageInfo_FireWeapon proto.InternalMessageInfo
 
-func (m *RotTracks) GetNid() []uint64 {
+func (m *FireWeapon) GetOwner() uint64 {
 	if m != nil {
-		return m.Nid
+		return m.Owner
 	}
-	return nil
+	return 0
 }
 
-func (m *RotTracks) GetR() []float32 {
+func (m *FireWeapon) GetKind() WeaponKind {
 	if m != nil {
-		return m.R
+		return m.Kind
 	}
-	return nil
+	return WeaponKind_MISSILE
 }
 
-type SpinTracks struct {
-	Nid                  []uint64  `protobuf:"varint,1,rep,packed,name=nid,proto3" json:"nid,omitempty"`
-	S                    []float32 `protobuf:"fixed32,2,rep,packed,name=s,proto3" json:"s,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+// Sent by the host when a laser fires, so that it can be drawn.
+type LaserBeam struct {
+	From                 *Vec2    `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To                   *Vec2    `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *SpinTracks) Reset()         { *m = SpinTracks{} }
-func (m *SpinTracks) String() string { return proto.CompactTextString(m) }
-func (*SpinTracks) ProtoMessage()    {}
-func (*SpinTracks) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae8bea4e98c5fae7, []int{6}
+func (m *LaserBeam) Reset()         { *m = LaserBeam{} }
+func (m *LaserBeam) String() string { return proto.CompactTextString(m) }
+func (*LaserBeam) ProtoMessage()    {}
+func (*LaserBeam) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{5}
 }
 
-func (m *SpinTracks) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SpinTracks.Unmarshal(m, b)
+func (m *LaserBeam) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LaserBeam.Unmarshal(m, b)
 }
-func (m *SpinTracks) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SpinTracks.Marshal(b, m, deterministic)
+func (m *LaserBeam) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LaserBeam.Marshal(b, m, deterministic)
 }
-func (m *SpinTracks) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SpinTracks.Merge(m, src)
+func (m *LaserBeam) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LaserBeam.Merge(m, src)
 }
-func (m *SpinTracks) XXX_Size() int {
-	return xxx_messageInfo_SpinTracks.Size(m)
+func (m *LaserBeam) XXX_Size() int {
+	return xxx_messageInfo_LaserBeam.Size(m)
 }
-func (m *SpinTracks) XXX_DiscardUnknown() {
-	xxx_messageInfo_SpinTracks.DiscardUnknown(m)
+func (m *LaserBeam) XXX_DiscardUnknown() {
+	xxx_messageInfo_LaserBeam.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_SpinTracks proto.InternalMessageInfo
+var xxx_messageInfo_LaserBeam proto.InternalMessageInfo
 
-func (m *SpinTracks) GetNid() []uint64 {
+func (m *LaserBeam) GetFrom() *Vec2 {
 	if m != nil {
-		return m.Nid
+		return m.From
 	}
 	return nil
 }
 
-func (m *SpinTracks) GetS() []float32 {
+func (m *LaserBeam) GetTo() *Vec2 {
 	if m != nil {
-		return m.S
+		return m.To
 	}
 	return nil
 }
 
-type ShipControlTrack struct {
+type SpawnMine struct {
 	Nid                  uint64   `protobuf:"varint,1,opt,name=nid,proto3" json:"nid,omitempty"`
-	Up                   bool     `protobuf:"varint,2,opt,name=up,proto3" json:"up,omitempty"`
-	Left                 bool     `protobuf:"varint,3,opt,name=left,proto3" json:"left,omitempty"`
-	Right                bool     `protobuf:"varint,4,opt,name=right,proto3" json:"right,omitempty"`
+	OwnerCid             int64    `protobuf:"varint,2,opt,name=owner_cid,json=ownerCid,proto3" json:"ownerCid,omitempty"`
+	Team                 int32    `protobuf:"varint,3,opt,name=team,proto3" json:"team,omitempty"`
+	Pos                  *Vec2    `protobuf:"bytes,4,opt,name=pos,proto3" json:"pos,omitempty"`
+	Momentum             *Vec2    `protobuf:"bytes,5,opt,name=momentum,proto3" json:"momentum,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ShipControlTrack) Reset()         { *m = ShipControlTrack{} }
-func (m *ShipControlTrack) String() string { return proto.CompactTextString(m) }
-func (*ShipControlTrack) ProtoMessage()    {}
-func (*ShipControlTrack) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae8bea4e98c5fae7, []int{7}
+func (m *SpawnMine) Reset()         { *m = SpawnMine{} }
+func (m *SpawnMine) String() string { return proto.CompactTextString(m) }
+func (*SpawnMine) ProtoMessage()    {}
+func (*SpawnMine) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{6}
 }
 
-func (m *ShipControlTrack) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ShipControlTrack.Unmarshal(m, b)
+func (m *SpawnMine) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SpawnMine.Unmarshal(m, b)
 }
-func (m *ShipControlTrack) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ShipControlTrack.Marshal(b, m, deterministic)
+func (m *SpawnMine) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SpawnMine.Marshal(b, m, deterministic)
 }
-func (m *ShipControlTrack) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ShipControlTrack.Merge(m, src)
+func (m *SpawnMine) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SpawnMine.Merge(m, src)
 }
-func (m *ShipControlTrack) XXX_Size() int {
-	return xxx_messageInfo_ShipControlTrack.Size(m)
+func (m *SpawnMine) XXX_Size() int {
+	return xxx_messageInfo_SpawnMine.Size(m)
 }
-func (m *ShipControlTrack) XXX_DiscardUnknown() {
-	xxx_messageInfo_ShipControlTrack.DiscardUnknown(m)
+func (m *SpawnMine) XXX_DiscardUnknown() {
+	xxx_messageInfo_SpawnMine.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ShipControlTrack proto.InternalMessageInfo
+var xxx_messageInfo_SpawnMine proto.InternalMessageInfo
 
-func (m *ShipControlTrack) GetNid() uint64 {
+func (m *SpawnMine) GetNid() uint64 {
 	if m != nil {
 		return m.Nid
 	}
 	return 0
 }
 
-func (m *ShipControlTrack) GetUp() bool {
+func (m *SpawnMine) GetOwnerCid() int64 {
 	if m != nil {
-		return m.Up
+		return m.OwnerCid
 	}
-	return false
+	return 0
 }
 
-func (m *ShipControlTrack) GetLeft() bool {
+func (m *SpawnMine) GetTeam() int32 {
 	if m != nil {
-		return m.Left
+		return m.Team
 	}
-	return false
+	return 0
 }
 
-func (m *ShipControlTrack) GetRight() bool {
+func (m *SpawnMine) GetPos() *Vec2 {
 	if m != nil {
-		return m.Right
+		return m.Pos
 	}
-	return false
+	return nil
+}
+
+func (m *SpawnMine) GetMomentum() *Vec2 {
+	if m != nil {
+		return m.Momentum
+	}
+	return nil
 }
 
 type DestroyEvent struct {
@@ -649,7 +817,7 @@ func (m *DestroyEvent) Reset()         { *m = DestroyEvent{} }
 func (m *DestroyEvent) String() string { return proto.CompactTextString(m) }
 func (*DestroyEvent) ProtoMessage()    {}
 func (*DestroyEvent) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae8bea4e98c5fae7, []int{8}
+	return fileDescriptor_ae8bea4e98c5fae7, []int{7}
 }
 
 func (m *DestroyEvent) XXX_Unmarshal(b []byte) error {
@@ -677,6 +845,71 @@ func (m *DestroyEvent) GetNid() uint64 {
 	return 0
 }
 
+// Sent by the host when an entity is damaged, with the health and shield it
+// has left.
+type DamageEvent struct {
+	Nid                  uint64   `protobuf:"varint,1,opt,name=nid,proto3" json:"nid,omitempty"`
+	Damage               float32  `protobuf:"fixed32,2,opt,name=damage,proto3" json:"damage,omitempty"`
+	Health               float32  `protobuf:"fixed32,3,opt,name=health,proto3" json:"health,omitempty"`
+	Shield               float32  `protobuf:"fixed32,4,opt,name=shield,proto3" json:"shield,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DamageEvent) Reset()         { *m = DamageEvent{} }
+func (m *DamageEvent) String() string { return proto.CompactTextString(m) }
+func (*DamageEvent) ProtoMessage()    {}
+func (*DamageEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{8}
+}
+
+func (m *DamageEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DamageEvent.Unmarshal(m, b)
+}
+func (m *DamageEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DamageEvent.Marshal(b, m, deterministic)
+}
+func (m *DamageEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DamageEvent.Merge(m, src)
+}
+func (m *DamageEvent) XXX_Size() int {
+	return xxx_messageInfo_DamageEvent.Size(m)
+}
+func (m *DamageEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_DamageEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DamageEvent proto.InternalMessageInfo
+
+func (m *DamageEvent) GetNid() uint64 {
+	if m != nil {
+		return m.Nid
+	}
+	return 0
+}
+
+func (m *DamageEvent) GetDamage() float32 {
+	if m != nil {
+		return m.Damage
+	}
+	return 0
+}
+
+func (m *DamageEvent) GetHealth() float32 {
+	if m != nil {
+		return m.Health
+	}
+	return 0
+}
+
+func (m *DamageEvent) GetShield() float32 {
+	if m != nil {
+		return m.Shield
+	}
+	return 0
+}
+
 type ShootMissile struct {
 	Owner                uint64   `protobuf:"varint,1,opt,name=owner,proto3" json:"owner,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -718,12 +951,17 @@ func (m *ShootMissile) GetOwner() uint64 {
 
 // Server is always authority
 type SpawnMissile struct {
-	Nid                  uint64   `protobuf:"varint,6,opt,name=nid,proto3" json:"nid,omitempty"`
-	Owner                uint64   `protobuf:"varint,1,opt,name=owner,proto3" json:"owner,omitempty"`
-	Pos                  *Vec2    `protobuf:"bytes,2,opt,name=pos,proto3" json:"pos,omitempty"`
-	Momentum             *Vec2    `protobuf:"bytes,3,opt,name=momentum,proto3" json:"momentum,omitempty"`
-	Rot                  float32  `protobuf:"fixed32,4,opt,name=rot,proto3" json:"rot,omitempty"`
-	Spin                 float32  `protobuf:"fixed32,5,opt,name=spin,proto3" json:"spin,omitempty"`
+	Nid      uint64  `protobuf:"varint,6,opt,name=nid,proto3" json:"nid,omitempty"`
+	Owner    uint64  `protobuf:"varint,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	Pos      *Vec2   `protobuf:"bytes,2,opt,name=pos,proto3" json:"pos,omitempty"`
+	Momentum *Vec2   `protobuf:"bytes,3,opt,name=momentum,proto3" json:"momentum,omitempty"`
+	Rot      float32 `protobuf:"fixed32,4,opt,name=rot,proto3" json:"rot,omitempty"`
+	Spin     float32 `protobuf:"fixed32,5,opt,name=spin,proto3" json:"spin,omitempty"`
+	OwnerCid int64   `protobuf:"varint,7,opt,name=owner_cid,json=ownerCid,proto3" json:"ownerCid,omitempty"`
+	Team     int32   `protobuf:"varint,8,opt,name=team,proto3" json:"team,omitempty"`
+	// The nid of the ship a homing missile steers towards, or 0 if it flies
+	// straight.
+	Target               uint64   `protobuf:"varint,9,opt,name=target,proto3" json:"target,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -796,9 +1034,35 @@ func (m *SpawnMissile) GetSpin() float32 {
 	return 0
 }
 
+func (m *SpawnMissile) GetOwnerCid() int64 {
+	if m != nil {
+		return m.OwnerCid
+	}
+	return 0
+}
+
+func (m *SpawnMissile) GetTeam() int32 {
+	if m != nil {
+		return m.Team
+	}
+	return 0
+}
+
+func (m *SpawnMissile) GetTarget() uint64 {
+	if m != nil {
+		return m.Target
+	}
+	return 0
+}
+
 type SpawnExplosion struct {
-	Pos                  *Vec2    `protobuf:"bytes,1,opt,name=pos,proto3" json:"pos,omitempty"`
-	Momentum             *Vec2    `protobuf:"bytes,2,opt,name=momentum,proto3" json:"momentum,omitempty"`
+	Pos      *Vec2 `protobuf:"bytes,1,opt,name=pos,proto3" json:"pos,omitempty"`
+	Momentum *Vec2 `protobuf:"bytes,2,opt,name=momentum,proto3" json:"momentum,omitempty"`
+	// The cid of the player credited with anything the explosion destroys, or 0
+	// if nobody is.
+	CausedBy int64 `protobuf:"varint,3,opt,name=caused_by,json=causedBy,proto3" json:"causedBy,omitempty"`
+	// The nid of the entity which exploded.
+	Nid                  uint64   `protobuf:"varint,4,opt,name=nid,proto3" json:"nid,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -843,13 +1107,29 @@ func (m *SpawnExplosion) GetMomentum() *Vec2 {
 	return nil
 }
 
+func (m *SpawnExplosion) GetCausedBy() int64 {
+	if m != nil {
+		return m.CausedBy
+	}
+	return 0
+}
+
+func (m *SpawnExplosion) GetNid() uint64 {
+	if m != nil {
+		return m.Nid
+	}
+	return 0
+}
+
 type SpawnShip struct {
-	Nid                  uint64   `protobuf:"varint,1,opt,name=nid,proto3" json:"nid,omitempty"`
-	Authority            int64    `protobuf:"varint,2,opt,name=authority,proto3" json:"authority,omitempty"`
-	Pos                  *Vec2    `protobuf:"bytes,3,opt,name=pos,proto3" json:"pos,omitempty"`
-	Momentum             *Vec2    `protobuf:"bytes,4,opt,name=momentum,proto3" json:"momentum,omitempty"`
-	Rot                  float32  `protobuf:"fixed32,5,opt,name=rot,proto3" json:"rot,omitempty"`
-	Spin                 float32  `protobuf:"fixed32,6,opt,name=spin,proto3" json:"spin,omitempty"`
+	Nid       uint64  `protobuf:"varint,1,opt,name=nid,proto3" json:"nid,omitempty"`
+	Authority int64   `protobuf:"varint,2,opt,name=authority,proto3" json:"authority,omitempty"`
+	Pos       *Vec2   `protobuf:"bytes,3,opt,name=pos,proto3" json:"pos,omitempty"`
+	Momentum  *Vec2   `protobuf:"bytes,4,opt,name=momentum,proto3" json:"momentum,omitempty"`
+	Rot       float32 `protobuf:"fixed32,5,opt,name=rot,proto3" json:"rot,omitempty"`
+	Spin      float32 `protobuf:"fixed32,6,opt,name=spin,proto3" json:"spin,omitempty"`
+	// 0 if the ship isn't on a team.
+	Team                 int32    `protobuf:"varint,7,opt,name=team,proto3" json:"team,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -922,8 +1202,18 @@ func (m *SpawnShip) GetSpin() float32 {
 	return 0
 }
 
+func (m *SpawnShip) GetTeam() int32 {
+	if m != nil {
+		return m.Team
+	}
+	return 0
+}
+
 type RegisterPlayer struct {
-	Cid                  int64    `protobuf:"varint,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	Cid int64 `protobuf:"varint,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// The team the player would like to be on, 0 for whichever the game mode
+	// chooses.
+	Team                 int32    `protobuf:"varint,2,opt,name=team,proto3" json:"team,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -961,6 +1251,301 @@ func (m *RegisterPlayer) GetCid() int64 {
 	return 0
 }
 
+func (m *RegisterPlayer) GetTeam() int32 {
+	if m != nil {
+		return m.Team
+	}
+	return 0
+}
+
+// Sent to the host when a player disconnects.
+type PlayerLeft struct {
+	Cid                  int64    `protobuf:"varint,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PlayerLeft) Reset()         { *m = PlayerLeft{} }
+func (m *PlayerLeft) String() string { return proto.CompactTextString(m) }
+func (*PlayerLeft) ProtoMessage()    {}
+func (*PlayerLeft) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{14}
+}
+
+func (m *PlayerLeft) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlayerLeft.Unmarshal(m, b)
+}
+func (m *PlayerLeft) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlayerLeft.Marshal(b, m, deterministic)
+}
+func (m *PlayerLeft) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlayerLeft.Merge(m, src)
+}
+func (m *PlayerLeft) XXX_Size() int {
+	return xxx_messageInfo_PlayerLeft.Size(m)
+}
+func (m *PlayerLeft) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlayerLeft.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlayerLeft proto.InternalMessageInfo
+
+func (m *PlayerLeft) GetCid() int64 {
+	if m != nil {
+		return m.Cid
+	}
+	return 0
+}
+
+// Sent by the host whenever the score changes.  Suicides are deaths to the sun
+// or to the player's own missiles, and are counted in deaths as well.
+type Scoreboard struct {
+	Scores               []*Score `protobuf:"bytes,1,rep,name=scores,proto3" json:"scores,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Scoreboard) Reset()         { *m = Scoreboard{} }
+func (m *Scoreboard) String() string { return proto.CompactTextString(m) }
+func (*Scoreboard) ProtoMessage()    {}
+func (*Scoreboard) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{15}
+}
+
+func (m *Scoreboard) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Scoreboard.Unmarshal(m, b)
+}
+func (m *Scoreboard) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Scoreboard.Marshal(b, m, deterministic)
+}
+func (m *Scoreboard) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Scoreboard.Merge(m, src)
+}
+func (m *Scoreboard) XXX_Size() int {
+	return xxx_messageInfo_Scoreboard.Size(m)
+}
+func (m *Scoreboard) XXX_DiscardUnknown() {
+	xxx_messageInfo_Scoreboard.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Scoreboard proto.InternalMessageInfo
+
+func (m *Scoreboard) GetScores() []*Score {
+	if m != nil {
+		return m.Scores
+	}
+	return nil
+}
+
+type Score struct {
+	Cid      int64 `protobuf:"varint,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	Kills    int32 `protobuf:"varint,2,opt,name=kills,proto3" json:"kills,omitempty"`
+	Deaths   int32 `protobuf:"varint,3,opt,name=deaths,proto3" json:"deaths,omitempty"`
+	Suicides int32 `protobuf:"varint,4,opt,name=suicides,proto3" json:"suicides,omitempty"`
+	// The player's team, in game modes with teams.
+	Team int32 `protobuf:"varint,5,opt,name=team,proto3" json:"team,omitempty"`
+	// Whether the player has left the game.  Their score is kept for the
+	// results.
+	Departed             bool     `protobuf:"varint,6,opt,name=departed,proto3" json:"departed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Score) Reset()         { *m = Score{} }
+func (m *Score) String() string { return proto.CompactTextString(m) }
+func (*Score) ProtoMessage()    {}
+func (*Score) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{16}
+}
+
+func (m *Score) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Score.Unmarshal(m, b)
+}
+func (m *Score) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Score.Marshal(b, m, deterministic)
+}
+func (m *Score) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Score.Merge(m, src)
+}
+func (m *Score) XXX_Size() int {
+	return xxx_messageInfo_Score.Size(m)
+}
+func (m *Score) XXX_DiscardUnknown() {
+	xxx_messageInfo_Score.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Score proto.InternalMessageInfo
+
+func (m *Score) GetCid() int64 {
+	if m != nil {
+		return m.Cid
+	}
+	return 0
+}
+
+func (m *Score) GetKills() int32 {
+	if m != nil {
+		return m.Kills
+	}
+	return 0
+}
+
+func (m *Score) GetDeaths() int32 {
+	if m != nil {
+		return m.Deaths
+	}
+	return 0
+}
+
+func (m *Score) GetSuicides() int32 {
+	if m != nil {
+		return m.Suicides
+	}
+	return 0
+}
+
+func (m *Score) GetTeam() int32 {
+	if m != nil {
+		return m.Team
+	}
+	return 0
+}
+
+func (m *Score) GetDeparted() bool {
+	if m != nil {
+		return m.Departed
+	}
+	return false
+}
+
+// Sent by the host whenever the match changes phase.
+type MatchState struct {
+	Phase MatchState_Phase `protobuf:"varint,1,opt,name=phase,proto3,enum=spaceagon.MatchState.Phase" json:"phase,omitempty"`
+	// Seconds until the phase ends, or 0 if it doesn't end on a timer.
+	TimeLeft             float32  `protobuf:"fixed32,2,opt,name=time_left,json=timeLeft,proto3" json:"timeLeft,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MatchState) Reset()         { *m = MatchState{} }
+func (m *MatchState) String() string { return proto.CompactTextString(m) }
+func (*MatchState) ProtoMessage()    {}
+func (*MatchState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{17}
+}
+
+func (m *MatchState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MatchState.Unmarshal(m, b)
+}
+func (m *MatchState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MatchState.Marshal(b, m, deterministic)
+}
+func (m *MatchState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MatchState.Merge(m, src)
+}
+func (m *MatchState) XXX_Size() int {
+	return xxx_messageInfo_MatchState.Size(m)
+}
+func (m *MatchState) XXX_DiscardUnknown() {
+	xxx_messageInfo_MatchState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MatchState proto.InternalMessageInfo
+
+func (m *MatchState) GetPhase() MatchState_Phase {
+	if m != nil {
+		return m.Phase
+	}
+	return MatchState_WAITING_FOR_PLAYERS
+}
+
+func (m *MatchState) GetTimeLeft() float32 {
+	if m != nil {
+		return m.TimeLeft
+	}
+	return 0
+}
+
+// The rules which move a match between phases.  A zero disables a rule.
+type MatchRules struct {
+	// Players needed for the countdown to start.
+	MinPlayers int32 `protobuf:"varint,1,opt,name=min_players,json=minPlayers,proto3" json:"minPlayers,omitempty"`
+	// Seconds of countdown, and of live play.
+	Countdown float32 `protobuf:"fixed32,2,opt,name=countdown,proto3" json:"countdown,omitempty"`
+	TimeLimit float32 `protobuf:"fixed32,3,opt,name=time_limit,json=timeLimit,proto3" json:"timeLimit,omitempty"`
+	// Kills which win the match outright.
+	KillTarget int32 `protobuf:"varint,4,opt,name=kill_target,json=killTarget,proto3" json:"killTarget,omitempty"`
+	// Whether teammates can damage each other.
+	FriendlyFire         bool     `protobuf:"varint,5,opt,name=friendly_fire,json=friendlyFire,proto3" json:"friendlyFire,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MatchRules) Reset()         { *m = MatchRules{} }
+func (m *MatchRules) String() string { return proto.CompactTextString(m) }
+func (*MatchRules) ProtoMessage()    {}
+func (*MatchRules) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{18}
+}
+
+func (m *MatchRules) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MatchRules.Unmarshal(m, b)
+}
+func (m *MatchRules) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MatchRules.Marshal(b, m, deterministic)
+}
+func (m *MatchRules) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MatchRules.Merge(m, src)
+}
+func (m *MatchRules) XXX_Size() int {
+	return xxx_messageInfo_MatchRules.Size(m)
+}
+func (m *MatchRules) XXX_DiscardUnknown() {
+	xxx_messageInfo_MatchRules.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MatchRules proto.InternalMessageInfo
+
+func (m *MatchRules) GetMinPlayers() int32 {
+	if m != nil {
+		return m.MinPlayers
+	}
+	return 0
+}
+
+func (m *MatchRules) GetCountdown() float32 {
+	if m != nil {
+		return m.Countdown
+	}
+	return 0
+}
+
+func (m *MatchRules) GetTimeLimit() float32 {
+	if m != nil {
+		return m.TimeLimit
+	}
+	return 0
+}
+
+func (m *MatchRules) GetKillTarget() int32 {
+	if m != nil {
+		return m.KillTarget
+	}
+	return 0
+}
+
+func (m *MatchRules) GetFriendlyFire() bool {
+	if m != nil {
+		return m.FriendlyFire
+	}
+	return false
+}
+
 type Vec2 struct {
 	X                    float32  `protobuf:"fixed32,1,opt,name=x,proto3" json:"x,omitempty"`
 	Y                    float32  `protobuf:"fixed32,2,opt,name=y,proto3" json:"y,omitempty"`
@@ -973,7 +1558,7 @@ func (m *Vec2) Reset()         { *m = Vec2{} }
 func (m *Vec2) String() string { return proto.CompactTextString(m) }
 func (*Vec2) ProtoMessage()    {}
 func (*Vec2) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae8bea4e98c5fae7, []int{14}
+	return fileDescriptor_ae8bea4e98c5fae7, []int{19}
 }
 
 func (m *Vec2) XXX_Unmarshal(b []byte) error {
@@ -1008,75 +1593,254 @@ func (m *Vec2) GetY() float32 {
 	return 0
 }
 
+// A recording of a game is a ReplayHeader, followed by a ReplayStep for every
+// call to Game.Step, each framed by protostream.
+type ReplayHeader struct {
+	Seed                 int64       `protobuf:"varint,1,opt,name=seed,proto3" json:"seed,omitempty"`
+	ServerAuthoritative  bool        `protobuf:"varint,2,opt,name=server_authoritative,json=serverAuthoritative,proto3" json:"serverAuthoritative,omitempty"`
+	RollbackTicks        int32       `protobuf:"varint,3,opt,name=rollback_ticks,json=rollbackTicks,proto3" json:"rollbackTicks,omitempty"`
+	Rules                *MatchRules `protobuf:"bytes,4,opt,name=rules,proto3" json:"rules,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *ReplayHeader) Reset()         { *m = ReplayHeader{} }
+func (m *ReplayHeader) String() string { return proto.CompactTextString(m) }
+func (*ReplayHeader) ProtoMessage()    {}
+func (*ReplayHeader) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{20}
+}
+
+func (m *ReplayHeader) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplayHeader.Unmarshal(m, b)
+}
+func (m *ReplayHeader) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplayHeader.Marshal(b, m, deterministic)
+}
+func (m *ReplayHeader) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplayHeader.Merge(m, src)
+}
+func (m *ReplayHeader) XXX_Size() int {
+	return xxx_messageInfo_ReplayHeader.Size(m)
+}
+func (m *ReplayHeader) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplayHeader.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplayHeader proto.InternalMessageInfo
+
+func (m *ReplayHeader) GetSeed() int64 {
+	if m != nil {
+		return m.Seed
+	}
+	return 0
+}
+
+func (m *ReplayHeader) GetServerAuthoritative() bool {
+	if m != nil {
+		return m.ServerAuthoritative
+	}
+	return false
+}
+
+func (m *ReplayHeader) GetRollbackTicks() int32 {
+	if m != nil {
+		return m.RollbackTicks
+	}
+	return 0
+}
+
+func (m *ReplayHeader) GetRules() *MatchRules {
+	if m != nil {
+		return m.Rules
+	}
+	return nil
+}
+
+type ReplayStep struct {
+	Dt    float32 `protobuf:"fixed32,1,opt,name=dt,proto3" json:"dt,omitempty"`
+	Memos []*Memo `protobuf:"bytes,2,rep,name=memos,proto3" json:"memos,omitempty"`
+	// Set when the host's game mode changed before the step.
+	GameMode             string   `protobuf:"bytes,3,opt,name=game_mode,json=gameMode,proto3" json:"gameMode,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplayStep) Reset()         { *m = ReplayStep{} }
+func (m *ReplayStep) String() string { return proto.CompactTextString(m) }
+func (*ReplayStep) ProtoMessage()    {}
+func (*ReplayStep) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae8bea4e98c5fae7, []int{21}
+}
+
+func (m *ReplayStep) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplayStep.Unmarshal(m, b)
+}
+func (m *ReplayStep) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplayStep.Marshal(b, m, deterministic)
+}
+func (m *ReplayStep) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplayStep.Merge(m, src)
+}
+func (m *ReplayStep) XXX_Size() int {
+	return xxx_messageInfo_ReplayStep.Size(m)
+}
+func (m *ReplayStep) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplayStep.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplayStep proto.InternalMessageInfo
+
+func (m *ReplayStep) GetDt() float32 {
+	if m != nil {
+		return m.Dt
+	}
+	return 0
+}
+
+func (m *ReplayStep) GetMemos() []*Memo {
+	if m != nil {
+		return m.Memos
+	}
+	return nil
+}
+
+func (m *ReplayStep) GetGameMode() string {
+	if m != nil {
+		return m.GameMode
+	}
+	return ""
+}
+
 func init() {
+	proto.RegisterEnum("spaceagon.WeaponKind", WeaponKind_name, WeaponKind_value)
+	proto.RegisterEnum("spaceagon.MatchState.Phase", MatchState_Phase_name, MatchState_Phase_value)
 	proto.RegisterType((*ClientInitialize)(nil), "spaceagon.ClientInitialize")
 	proto.RegisterType((*Memos)(nil), "spaceagon.Memos")
 	proto.RegisterType((*Memo)(nil), "spaceagon.Memo")
-	proto.RegisterType((*PosTracks)(nil), "spaceagon.PosTracks")
-	proto.RegisterType((*MomentumTracks)(nil), "spaceagon.MomentumTracks")
-	proto.RegisterType((*RotTracks)(nil), "spaceagon.RotTracks")
-	proto.RegisterType((*SpinTracks)(nil), "spaceagon.SpinTracks")
 	proto.RegisterType((*ShipControlTrack)(nil), "spaceagon.ShipControlTrack")
+	proto.RegisterType((*FireWeapon)(nil), "spaceagon.FireWeapon")
+	proto.RegisterType((*LaserBeam)(nil), "spaceagon.LaserBeam")
+	proto.RegisterType((*SpawnMine)(nil), "spaceagon.SpawnMine")
 	proto.RegisterType((*DestroyEvent)(nil), "spaceagon.DestroyEvent")
+	proto.RegisterType((*DamageEvent)(nil), "spaceagon.DamageEvent")
 	proto.RegisterType((*ShootMissile)(nil), "spaceagon.ShootMissile")
 	proto.RegisterType((*SpawnMissile)(nil), "spaceagon.SpawnMissile")
 	proto.RegisterType((*SpawnExplosion)(nil), "spaceagon.SpawnExplosion")
 	proto.RegisterType((*SpawnShip)(nil), "spaceagon.SpawnShip")
 	proto.RegisterType((*RegisterPlayer)(nil), "spaceagon.RegisterPlayer")
+	proto.RegisterType((*PlayerLeft)(nil), "spaceagon.PlayerLeft")
+	proto.RegisterType((*Scoreboard)(nil), "spaceagon.Scoreboard")
+	proto.RegisterType((*Score)(nil), "spaceagon.Score")
+	proto.RegisterType((*MatchState)(nil), "spaceagon.MatchState")
+	proto.RegisterType((*MatchRules)(nil), "spaceagon.MatchRules")
 	proto.RegisterType((*Vec2)(nil), "spaceagon.vec2")
+	proto.RegisterType((*ReplayHeader)(nil), "spaceagon.ReplayHeader")
+	proto.RegisterType((*ReplayStep)(nil), "spaceagon.ReplayStep")
 }
 
 func init() { proto.RegisterFile("game/pb/messages.proto", fileDescriptor_ae8bea4e98c5fae7) }
 
 var fileDescriptor_ae8bea4e98c5fae7 = []byte{
-	// 773 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x55, 0xc1, 0x6e, 0xe3, 0x36,
-	0x10, 0x8d, 0x24, 0xdb, 0xb0, 0xc7, 0x8e, 0xe3, 0xb2, 0x49, 0xab, 0xa2, 0x39, 0xb8, 0x6a, 0x5a,
-	0x18, 0x48, 0x6b, 0x03, 0x29, 0x0a, 0xb4, 0x97, 0x1c, 0x9c, 0x04, 0x70, 0x51, 0x04, 0x08, 0x98,
-	0x9e, 0x7a, 0xa8, 0x2b, 0x2b, 0x5c, 0x9b, 0x58, 0x49, 0x24, 0x48, 0x2a, 0x89, 0xf7, 0x9b, 0xf6,
-	0xb0, 0xfb, 0x87, 0x0b, 0x92, 0xa6, 0x2c, 0x47, 0x06, 0x36, 0x7b, 0xe3, 0x9b, 0x99, 0xf7, 0x66,
-	0xc4, 0x99, 0xa1, 0xe0, 0x9b, 0x65, 0x9c, 0x91, 0x09, 0x5f, 0x4c, 0x32, 0x22, 0x65, 0xbc, 0x24,
-	0x72, 0xcc, 0x05, 0x53, 0x0c, 0x75, 0x24, 0x8f, 0x13, 0x12, 0x2f, 0x59, 0x1e, 0x9d, 0xc1, 0xe0,
-	0x2a, 0xa5, 0x24, 0x57, 0x7f, 0xe5, 0x54, 0xd1, 0x38, 0xa5, 0xef, 0x08, 0x1a, 0x40, 0x90, 0xd0,
-	0x87, 0xd0, 0x1b, 0x7a, 0xa3, 0x00, 0xeb, 0x63, 0x34, 0x86, 0xe6, 0x2d, 0xc9, 0x98, 0x44, 0x3f,
-	0x41, 0x33, 0xd3, 0x87, 0xd0, 0x1b, 0x06, 0xa3, 0xee, 0xc5, 0xd1, 0xb8, 0x54, 0x1a, 0xeb, 0x00,
-	0x6c, 0xbd, 0xd1, 0x87, 0x16, 0x34, 0x34, 0x46, 0x03, 0xf0, 0x15, 0xb3, 0x4a, 0xb3, 0x03, 0xec,
-	0x2b, 0x86, 0x7e, 0x84, 0x1e, 0x79, 0x24, 0x62, 0xcd, 0x72, 0x32, 0x5f, 0x14, 0x2a, 0xf4, 0x37,
-	0xbe, 0xae, 0xb3, 0x4e, 0x0b, 0x85, 0x4e, 0xa1, 0xed, 0x60, 0x18, 0x0c, 0xbd, 0x51, 0x7b, 0x76,
-	0x80, 0x4b, 0x0b, 0xfa, 0x1d, 0x80, 0x33, 0x39, 0x57, 0x22, 0x4e, 0xde, 0xca, 0x10, 0x86, 0xde,
-	0xa8, 0x7b, 0x71, 0x5c, 0xa9, 0xe4, 0x8e, 0xc9, 0x7f, 0x8c, 0x6f, 0xe6, 0xe1, 0x0e, 0x77, 0x00,
-	0x5d, 0xc3, 0x51, 0xc6, 0x32, 0x92, 0xab, 0x22, 0x73, 0xdc, 0xae, 0xe1, 0x7e, 0x57, 0xfd, 0x8a,
-	0x4d, 0x44, 0x29, 0xd0, 0xcf, 0x76, 0x2c, 0x3a, 0xb9, 0x60, 0xca, 0x09, 0xf4, 0x6a, 0xc9, 0x31,
-	0x53, 0xdb, 0xe4, 0xc2, 0x01, 0xf4, 0x07, 0x74, 0x25, 0xa7, 0xb9, 0xe3, 0x1d, 0x1a, 0xde, 0x49,
-	0x85, 0x77, 0xcf, 0x69, 0x5e, 0x12, 0x41, 0x96, 0x08, 0xfd, 0x0d, 0x48, 0xae, 0x28, 0x9f, 0x27,
-	0x2c, 0x57, 0x82, 0xa5, 0x56, 0x21, 0xec, 0x1b, 0x81, 0xef, 0xab, 0x02, 0x2b, 0xca, 0xaf, 0x6c,
-	0x8c, 0x61, 0xce, 0x3c, 0x3c, 0x90, 0x2f, 0x6c, 0xe8, 0x12, 0x0e, 0x1f, 0x88, 0x54, 0x82, 0xad,
-	0xe7, 0xe4, 0x91, 0xe4, 0x2a, 0x1c, 0x18, 0x9d, 0x6f, 0x2b, 0x3a, 0xd7, 0xd6, 0x7f, 0xa3, 0xdd,
-	0x33, 0x0f, 0xf7, 0x1e, 0x2a, 0x58, 0xf3, 0xe5, 0x8a, 0x31, 0x35, 0xcf, 0xa8, 0x94, 0x34, 0x25,
-	0xe1, 0x57, 0x35, 0xfe, 0xbd, 0xf6, 0xdf, 0x5a, 0xb7, 0xe6, 0xcb, 0x0a, 0x36, 0x7c, 0x1e, 0x3f,
-	0xe5, 0x25, 0x1f, 0xd5, 0xf9, 0xda, 0x5f, 0xe5, 0x57, 0xb0, 0xee, 0xa1, 0xe5, 0x93, 0x67, 0x9e,
-	0x32, 0x49, 0x59, 0x1e, 0x7e, 0x5d, 0xeb, 0xa1, 0x51, 0xb8, 0x71, 0x01, 0xba, 0x87, 0x72, 0xc7,
-	0xa2, 0x7b, 0x68, 0x55, 0xf4, 0xfd, 0x84, 0xc7, 0xb5, 0x1e, 0x1a, 0x01, 0x7d, 0x9f, 0xba, 0x87,
-	0xd2, 0x01, 0x9d, 0x5c, 0x90, 0x25, 0x95, 0x8a, 0x88, 0x39, 0x4f, 0xe3, 0x35, 0x11, 0xe1, 0x49,
-	0x2d, 0x39, 0xde, 0x44, 0xdc, 0x99, 0x00, 0x9d, 0x5c, 0xec, 0x58, 0xa6, 0x5d, 0xe8, 0x08, 0x92,
-	0x50, 0xae, 0x97, 0x6e, 0xda, 0x86, 0x56, 0x9c, 0xa8, 0x22, 0x4e, 0xa3, 0x3f, 0xa1, 0x53, 0xce,
-	0xad, 0xde, 0xc0, 0xdc, 0x6c, 0x60, 0x30, 0x6a, 0x60, 0x7d, 0x44, 0x3d, 0xf0, 0x9e, 0x43, 0x7f,
-	0x18, 0x8c, 0x7c, 0xec, 0x3d, 0x6b, 0xb4, 0x0e, 0x03, 0x8b, 0xd6, 0xd1, 0x25, 0xf4, 0x77, 0xc7,
-	0xf6, 0x0b, 0xf9, 0xe7, 0xd0, 0x29, 0xa7, 0x76, 0x3f, 0x55, 0x38, 0xaa, 0x88, 0x7e, 0x01, 0xd8,
-	0x8e, 0xea, 0xfe, 0x68, 0xe9, 0xa2, 0x65, 0xf4, 0x1f, 0x0c, 0x5e, 0xce, 0xe5, 0x96, 0xe3, 0x39,
-	0x4e, 0x1f, 0xfc, 0x82, 0x9b, 0x97, 0xa0, 0x8d, 0xfd, 0x82, 0x23, 0x04, 0x8d, 0x94, 0xbc, 0x51,
-	0x76, 0xf5, 0xb1, 0x39, 0xa3, 0x63, 0x68, 0x0a, 0xba, 0x5c, 0xa9, 0xb0, 0x61, 0x8c, 0x16, 0x44,
-	0x43, 0xe8, 0x55, 0xe7, 0xb5, 0xae, 0x1d, 0x9d, 0x41, 0xaf, 0x3a, 0x91, 0x5a, 0x87, 0x3d, 0xe5,
-	0x44, 0x6c, 0x62, 0x2c, 0x88, 0xde, 0x7b, 0xd0, 0xab, 0x0e, 0x9e, 0x13, 0x6a, 0x6d, 0x8b, 0xdc,
-	0x4b, 0x44, 0x3f, 0x40, 0xc0, 0x99, 0x34, 0xb5, 0xef, 0x3e, 0x87, 0x8f, 0x24, 0xb9, 0xc0, 0xda,
-	0x87, 0xce, 0xa1, 0xed, 0xde, 0x10, 0xf3, 0x45, 0x7b, 0xe2, 0xca, 0x00, 0x9d, 0x57, 0x30, 0xfb,
-	0x91, 0x3e, 0xd6, 0x47, 0x7d, 0x19, 0xfa, 0x35, 0x08, 0x9b, 0xc6, 0x64, 0xce, 0xd1, 0xff, 0xd0,
-	0xdf, 0x1d, 0x72, 0x57, 0x87, 0xf7, 0xca, 0x3a, 0xfc, 0xcf, 0xd4, 0x11, 0x7d, 0xf4, 0xa0, 0x53,
-	0xae, 0xc1, 0x9e, 0x96, 0x9d, 0x42, 0x27, 0x2e, 0xd4, 0x8a, 0x09, 0xaa, 0xd6, 0xf6, 0x0d, 0xc7,
-	0x5b, 0x83, 0xab, 0x26, 0x78, 0x65, 0x35, 0x8d, 0x57, 0xde, 0x4a, 0xb3, 0x7e, 0x2b, 0xad, 0xca,
-	0xad, 0x44, 0xd0, 0xdf, 0xdd, 0xbe, 0x3d, 0x7f, 0xb2, 0x08, 0x1a, 0x5a, 0xdb, 0xee, 0x83, 0x67,
-	0xc8, 0x6e, 0x1f, 0x7c, 0x8b, 0xd6, 0xd3, 0xd1, 0xbf, 0x3f, 0x2f, 0xa9, 0x5a, 0x15, 0x8b, 0x71,
-	0xc2, 0xb2, 0x49, 0x1a, 0x0b, 0x92, 0x11, 0x41, 0x26, 0xa6, 0xba, 0x5f, 0x75, 0x79, 0x93, 0xcd,
-	0x7f, 0x75, 0xd1, 0x32, 0xff, 0xd3, 0xdf, 0x3e, 0x05, 0x00, 0x00, 0xff, 0xff, 0x41, 0x26, 0x30,
-	0x2a, 0x69, 0x07, 0x00, 0x00,
+	// 1568 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x57, 0x4f, 0x73, 0x23, 0x47,
+	0x15, 0xf7, 0x8c, 0x34, 0xda, 0xd1, 0x93, 0xac, 0x4c, 0x7a, 0x1d, 0xef, 0x64, 0x77, 0x43, 0xcc,
+	0x2c, 0xa1, 0x04, 0x5b, 0xb1, 0x2b, 0x4b, 0x11, 0x28, 0xa8, 0xa2, 0xca, 0x5e, 0x2b, 0x91, 0x88,
+	0xe5, 0x5d, 0x5a, 0x4e, 0xb6, 0xe0, 0x32, 0x8c, 0x66, 0xda, 0x52, 0x97, 0xe7, 0x1f, 0xd3, 0x2d,
+	0xef, 0x8a, 0x2f, 0xc1, 0x81, 0x33, 0xdc, 0xb9, 0x71, 0xe0, 0x2b, 0xf0, 0x91, 0x38, 0x70, 0xa3,
+	0x5e, 0xf7, 0x8c, 0x34, 0xb2, 0x44, 0xe2, 0xca, 0xad, 0xdf, 0xbf, 0xee, 0xdf, 0xfb, 0xd3, 0xef,
+	0x75, 0xc3, 0xe1, 0x2c, 0x48, 0xd8, 0x49, 0x3e, 0x3d, 0x49, 0x98, 0x10, 0xc1, 0x8c, 0x89, 0xe3,
+	0xbc, 0xc8, 0x64, 0x46, 0xda, 0x22, 0x0f, 0x42, 0x16, 0xcc, 0xb2, 0xf4, 0xf1, 0x41, 0xa5, 0x22,
+	0x8b, 0x20, 0xbc, 0x29, 0x15, 0xbc, 0x37, 0xe0, 0xbc, 0x8c, 0x39, 0x4b, 0xe5, 0x28, 0xe5, 0x92,
+	0x07, 0x31, 0xff, 0x33, 0x23, 0x0e, 0x34, 0x42, 0x1e, 0xb9, 0xc6, 0x91, 0xd1, 0x6f, 0x50, 0x5c,
+	0x92, 0xcf, 0xe0, 0x40, 0xb0, 0xe2, 0x96, 0x15, 0x7e, 0xb0, 0x90, 0xf3, 0xac, 0xe0, 0x32, 0x90,
+	0xfc, 0x96, 0xb9, 0xe6, 0x91, 0xd1, 0xb7, 0xe9, 0x43, 0x2d, 0x3b, 0xad, 0x8b, 0xbc, 0x63, 0xb0,
+	0xc6, 0x2c, 0xc9, 0x04, 0xf9, 0x04, 0xac, 0x04, 0x17, 0xae, 0x71, 0xd4, 0xe8, 0x77, 0x5e, 0xbc,
+	0x77, 0xbc, 0x82, 0x74, 0x8c, 0x0a, 0x54, 0x4b, 0xbd, 0xbf, 0xd9, 0xd0, 0x44, 0x9a, 0x38, 0x60,
+	0xca, 0x4c, 0x1f, 0x3e, 0xdc, 0xa3, 0xa6, 0xcc, 0xc8, 0x33, 0xe8, 0xb2, 0x5b, 0x56, 0x2c, 0xb3,
+	0x94, 0xf9, 0xd3, 0x85, 0x74, 0xcd, 0x52, 0xd6, 0xa9, 0xb8, 0x67, 0x0b, 0x49, 0x9e, 0x82, 0x5d,
+	0x91, 0x6e, 0x03, 0x61, 0x0d, 0xf7, 0xe8, 0x8a, 0x43, 0x0e, 0xa0, 0x29, 0x59, 0x90, 0xb8, 0xcd,
+	0x23, 0xa3, 0x6f, 0x0d, 0xf7, 0xa8, 0xa2, 0xc8, 0x73, 0x68, 0xe9, 0x60, 0xb8, 0x87, 0x47, 0x46,
+	0xbf, 0xf3, 0xe2, 0xfd, 0x1a, 0xb6, 0x2b, 0x25, 0x18, 0x1a, 0xb4, 0x54, 0x21, 0x5f, 0x01, 0x11,
+	0x73, 0x9e, 0xfb, 0x61, 0x96, 0xca, 0x22, 0x8b, 0x7d, 0xc5, 0x76, 0x7b, 0xca, 0xf0, 0x49, 0xcd,
+	0x70, 0x32, 0xe7, 0xf9, 0x4b, 0xad, 0xa3, 0xf6, 0x18, 0x1a, 0xd4, 0x11, 0x77, 0x78, 0xe4, 0x37,
+	0xb0, 0x1f, 0x31, 0x21, 0x8b, 0x6c, 0xe9, 0xb3, 0x5b, 0x96, 0x4a, 0xd7, 0x51, 0xfb, 0x3c, 0xaa,
+	0xed, 0x73, 0xae, 0xe5, 0x03, 0x14, 0x0f, 0x0d, 0xda, 0x8d, 0x6a, 0x34, 0xda, 0x8b, 0x79, 0x96,
+	0x49, 0x3f, 0xe1, 0x42, 0xf0, 0x98, 0xb9, 0xef, 0x6f, 0xd9, 0x4f, 0x50, 0x3e, 0xd6, 0x62, 0xb4,
+	0x17, 0x35, 0x5a, 0xd9, 0xe7, 0xc1, 0xdb, 0x74, 0x65, 0x4f, 0xb6, 0xed, 0x51, 0x5e, 0xb7, 0xaf,
+	0xd1, 0xe4, 0x1c, 0xde, 0xd3, 0xf6, 0xec, 0x5d, 0x1e, 0x67, 0x82, 0x67, 0xa9, 0xfb, 0x50, 0xed,
+	0xf0, 0xe1, 0xdd, 0x1d, 0x06, 0x95, 0xc2, 0xd0, 0xa0, 0x3d, 0xb1, 0xc1, 0x21, 0x3f, 0x07, 0xd0,
+	0xbb, 0x60, 0x7c, 0xdc, 0x03, 0xb5, 0xc1, 0xc1, 0xdd, 0x0d, 0x30, 0x9e, 0x43, 0x83, 0xb6, 0x45,
+	0x45, 0xe0, 0xe1, 0x05, 0x9b, 0x71, 0x21, 0x59, 0xe1, 0xe7, 0x71, 0xb0, 0x64, 0x85, 0xfb, 0xc1,
+	0xd6, 0xe1, 0xb4, 0xd4, 0x78, 0xad, 0x14, 0xf0, 0xf0, 0x62, 0x83, 0x43, 0x7e, 0x0d, 0xdd, 0x28,
+	0x48, 0x82, 0x19, 0x2b, 0x33, 0xf0, 0x48, 0x6d, 0x71, 0x58, 0xcf, 0x80, 0x12, 0x57, 0x09, 0xe8,
+	0x44, 0x6b, 0x92, 0xfc, 0x02, 0x40, 0x84, 0x59, 0xc1, 0xa6, 0x59, 0x50, 0x44, 0xae, 0xab, 0x4c,
+	0x3f, 0xa8, 0x23, 0x5f, 0x09, 0x87, 0x06, 0xad, 0xa9, 0x92, 0x5f, 0x42, 0x27, 0x09, 0x64, 0x38,
+	0xf7, 0x85, 0x0c, 0x24, 0x73, 0x3f, 0xdc, 0xb2, 0x1c, 0xa3, 0x74, 0x82, 0x42, 0xb4, 0x4c, 0x56,
+	0x14, 0x5a, 0x5e, 0xf3, 0x82, 0xf9, 0x6f, 0x59, 0x90, 0x67, 0xa9, 0xfb, 0x78, 0xcb, 0xf2, 0x0b,
+	0x5e, 0xb0, 0x37, 0x4a, 0x88, 0x96, 0xd7, 0x2b, 0x0a, 0xc3, 0x1c, 0x07, 0x82, 0x15, 0xfe, 0x14,
+	0xaf, 0xc0, 0x93, 0xad, 0x30, 0x5f, 0xa0, 0xf0, 0x8c, 0x05, 0x09, 0x86, 0x39, 0xae, 0x88, 0x75,
+	0x76, 0x12, 0x9e, 0x32, 0xf7, 0xe9, 0xee, 0xec, 0x8c, 0x79, 0xca, 0x56, 0xd9, 0x41, 0x02, 0x71,
+	0xea, 0xa4, 0xf8, 0x31, 0xbb, 0x96, 0xee, 0x47, 0x5b, 0x38, 0x75, 0xfc, 0x2f, 0xd8, 0x35, 0x46,
+	0x15, 0xf2, 0x15, 0x75, 0xd6, 0x81, 0x76, 0xc1, 0x42, 0x9e, 0x63, 0x3b, 0x3a, 0xb3, 0xa1, 0x15,
+	0x84, 0x72, 0x11, 0xc4, 0xbf, 0x6d, 0xda, 0xe0, 0xf4, 0xbc, 0xff, 0x1a, 0xe0, 0xdc, 0xbd, 0x5a,
+	0xd8, 0xa9, 0xd2, 0xb2, 0x53, 0x35, 0x29, 0x2e, 0x49, 0x0f, 0xcc, 0x45, 0x5e, 0xf6, 0x25, 0x73,
+	0x91, 0x13, 0x02, 0x4d, 0x05, 0x43, 0xb5, 0x04, 0xaa, 0xd6, 0xe4, 0x00, 0xac, 0x82, 0xcf, 0xe6,
+	0x52, 0x75, 0x03, 0x9b, 0x6a, 0x02, 0x35, 0x31, 0x66, 0xae, 0xa5, 0x35, 0x71, 0x8d, 0xfb, 0x0b,
+	0xf6, 0x27, 0xb7, 0x75, 0x64, 0xf4, 0xf7, 0x29, 0x2e, 0x51, 0x4b, 0xf2, 0xf0, 0xc6, 0x7d, 0xa0,
+	0x8e, 0x54, 0x6b, 0xe4, 0x45, 0xd9, 0xdb, 0xd4, 0xb5, 0xb5, 0x25, 0xae, 0xc9, 0x53, 0x68, 0x0b,
+	0x16, 0x66, 0x69, 0x14, 0x14, 0x4b, 0xb7, 0xad, 0x04, 0x6b, 0x06, 0xf9, 0x14, 0x5a, 0x65, 0x1a,
+	0xe1, 0xc8, 0xe8, 0xf7, 0x36, 0xc2, 0xa3, 0x93, 0xf6, 0x15, 0x4f, 0x23, 0x5a, 0x2a, 0x79, 0x63,
+	0x80, 0x75, 0x72, 0x11, 0x7e, 0xf6, 0x36, 0x65, 0x45, 0xe9, 0xb6, 0x26, 0xc8, 0x4f, 0xa0, 0x79,
+	0xc3, 0xd3, 0xc8, 0x35, 0xbf, 0x6d, 0x43, 0xa5, 0xe2, 0xfd, 0x0e, 0xda, 0xab, 0x94, 0x93, 0x67,
+	0xd0, 0xbc, 0x2e, 0xb2, 0x44, 0x6d, 0xb6, 0xd9, 0x9d, 0x6f, 0x59, 0xf8, 0x82, 0x2a, 0x21, 0xf9,
+	0x58, 0xf5, 0x64, 0x73, 0xb7, 0x8a, 0x29, 0x33, 0xef, 0xef, 0x06, 0xb4, 0x57, 0xf5, 0xb0, 0x23,
+	0x2d, 0x4f, 0xa0, 0xad, 0x60, 0xfa, 0x38, 0x58, 0x54, 0xff, 0xa6, 0xb6, 0x62, 0xbc, 0xe4, 0x91,
+	0x8a, 0x29, 0x56, 0x26, 0xe6, 0xc8, 0x2a, 0x5b, 0xf3, 0x0f, 0xa1, 0x91, 0x67, 0xc2, 0x6d, 0xee,
+	0x3e, 0x12, 0x65, 0xe4, 0x39, 0xd8, 0x49, 0x96, 0xb0, 0x54, 0x2e, 0x12, 0xd7, 0xda, 0xad, 0xb7,
+	0x52, 0xf0, 0x8e, 0xa0, 0x5b, 0x6f, 0xa8, 0xdb, 0x10, 0xbd, 0x19, 0x74, 0x6a, 0x17, 0x7e, 0x87,
+	0x0f, 0x87, 0xd0, 0xd2, 0x2d, 0x40, 0x39, 0x60, 0xd2, 0x92, 0x42, 0xfe, 0x9c, 0x05, 0xb1, 0x9c,
+	0x2b, 0x07, 0x4c, 0x5a, 0x52, 0xc8, 0x17, 0x73, 0xce, 0xe2, 0x48, 0x79, 0x61, 0xd2, 0x92, 0xf2,
+	0x7e, 0x04, 0xdd, 0x7a, 0x6f, 0xde, 0x9d, 0x4f, 0xef, 0x3f, 0x06, 0x74, 0xeb, 0x2d, 0xb8, 0x02,
+	0xd4, 0x5a, 0x03, 0xda, 0x5d, 0x08, 0x65, 0xe4, 0xcc, 0x7b, 0x46, 0xae, 0xf1, 0x1d, 0x91, 0xc3,
+	0x73, 0x8b, 0x4c, 0x96, 0x3e, 0xe0, 0x12, 0xf3, 0x25, 0x72, 0x9e, 0xaa, 0xa0, 0x9b, 0x54, 0xad,
+	0x37, 0x13, 0xfc, 0xe0, 0xff, 0x24, 0xd8, 0xae, 0x25, 0xf8, 0x10, 0x5a, 0x32, 0x28, 0x66, 0x4c,
+	0xaa, 0xdb, 0xd1, 0xa4, 0x25, 0xe5, 0xfd, 0xc5, 0x80, 0xde, 0xe6, 0xe0, 0xa8, 0x3c, 0x32, 0xee,
+	0xe9, 0x91, 0xf9, 0x5d, 0x1e, 0x3d, 0x81, 0x76, 0x18, 0x2c, 0x04, 0x8b, 0xfc, 0xe9, 0x52, 0xf9,
+	0xdf, 0xa0, 0xb6, 0x66, 0x9c, 0x2d, 0xab, 0x30, 0x37, 0xd7, 0x85, 0xf1, 0xef, 0xaa, 0xb6, 0xd5,
+	0xf0, 0xd9, 0xae, 0x8b, 0xa7, 0xd0, 0xae, 0x5e, 0x45, 0xcb, 0xb2, 0xb6, 0xd7, 0x8c, 0x0a, 0x7c,
+	0xe3, 0x9e, 0xe0, 0x9b, 0xf7, 0x4c, 0x87, 0xb5, 0x9d, 0x8e, 0x56, 0x2d, 0x1d, 0x55, 0xc4, 0x1f,
+	0xac, 0x23, 0xee, 0x7d, 0x0e, 0xbd, 0xcd, 0xa1, 0xb8, 0xe3, 0xa1, 0x57, 0xd9, 0x99, 0x35, 0xbb,
+	0x1f, 0x00, 0xac, 0x5b, 0xf6, 0xb6, 0x8d, 0xf7, 0x39, 0xc0, 0x7a, 0xdc, 0x91, 0x3e, 0xb4, 0xd4,
+	0xb8, 0xab, 0xde, 0x7b, 0xce, 0xdd, 0xa9, 0x48, 0x4b, 0xb9, 0xf7, 0x57, 0x03, 0x2c, 0xc5, 0xd9,
+	0x81, 0xe3, 0x00, 0xac, 0x1b, 0x1e, 0xc7, 0xa2, 0x04, 0xa2, 0x09, 0x75, 0x03, 0x59, 0x20, 0xe7,
+	0xa2, 0x6c, 0x15, 0x25, 0x45, 0x1e, 0x83, 0x2d, 0x16, 0x3c, 0xe4, 0x11, 0xd3, 0x1d, 0xc3, 0xa2,
+	0x2b, 0x7a, 0xe5, 0x91, 0x55, 0xab, 0xbd, 0xc7, 0x60, 0x47, 0x2c, 0x0f, 0x0a, 0xc9, 0xf4, 0x7d,
+	0xb2, 0xe9, 0x8a, 0xf6, 0xfe, 0x65, 0x00, 0xac, 0x67, 0x30, 0xf9, 0x0c, 0xac, 0x7c, 0x1e, 0x08,
+	0xa6, 0xc0, 0xf5, 0x36, 0x1e, 0x7a, 0x6b, 0xad, 0xe3, 0xd7, 0xa8, 0x42, 0xb5, 0x26, 0x96, 0x97,
+	0xe4, 0x09, 0xd3, 0xe3, 0x4f, 0xb7, 0x0a, 0x1b, 0x19, 0x18, 0x3e, 0xef, 0x1b, 0xb0, 0x94, 0x32,
+	0x79, 0x04, 0x0f, 0xdf, 0x9c, 0x8e, 0xae, 0x46, 0x97, 0x5f, 0xfa, 0x5f, 0xbc, 0xa2, 0xfe, 0xeb,
+	0x8b, 0xd3, 0xdf, 0x0f, 0xe8, 0xc4, 0xd9, 0x23, 0xfb, 0xd0, 0x7e, 0xf9, 0xea, 0xeb, 0xcb, 0xab,
+	0xf3, 0x57, 0x6f, 0x2e, 0x1d, 0x83, 0xd8, 0xd0, 0xbc, 0x18, 0x7d, 0x33, 0x70, 0x4c, 0xe2, 0x40,
+	0x77, 0xf2, 0xf5, 0xf9, 0xf9, 0xe0, 0xd2, 0x3f, 0x1f, 0x9c, 0x5e, 0x0d, 0x9d, 0x06, 0x69, 0x83,
+	0x35, 0xb8, 0x3c, 0x1f, 0x9c, 0x3b, 0x4d, 0xef, 0x9f, 0x15, 0x6c, 0xba, 0x88, 0x99, 0x20, 0x1f,
+	0x43, 0x27, 0xe1, 0x69, 0xf9, 0x3a, 0xd2, 0x57, 0xc7, 0xa2, 0x90, 0xf0, 0x54, 0x67, 0x52, 0x60,
+	0xd1, 0x86, 0xd9, 0x22, 0x95, 0x6a, 0x70, 0x69, 0x90, 0x6b, 0x06, 0xf9, 0x08, 0x40, 0xbb, 0xc0,
+	0x13, 0x2e, 0xcb, 0xb6, 0xa6, 0x9c, 0xba, 0x40, 0x06, 0xee, 0x8e, 0x09, 0xf1, 0xcb, 0x0b, 0xac,
+	0x43, 0x0e, 0xc8, 0xba, 0x52, 0x1c, 0xf2, 0x0c, 0xf6, 0xaf, 0x0b, 0xce, 0xd2, 0x28, 0x5e, 0xfa,
+	0xb5, 0xa1, 0xda, 0xad, 0x98, 0x38, 0xcd, 0x3c, 0x0f, 0x9a, 0x58, 0xdb, 0xa4, 0x0b, 0xc6, 0x3b,
+	0x85, 0xd0, 0xa4, 0xc6, 0x3b, 0xa4, 0x96, 0x25, 0x20, 0x63, 0xe9, 0xfd, 0xc3, 0x80, 0x2e, 0x65,
+	0xe8, 0xc6, 0x90, 0x05, 0x11, 0x2b, 0x54, 0xb1, 0x33, 0x56, 0xd5, 0x8a, 0x5a, 0x7f, 0x8f, 0xdf,
+	0x09, 0xf9, 0x04, 0x7a, 0x45, 0x16, 0xc7, 0xd3, 0x20, 0xbc, 0xf1, 0x71, 0x86, 0x57, 0x15, 0xb5,
+	0x5f, 0x71, 0xaf, 0x90, 0x49, 0x9e, 0x83, 0x55, 0x60, 0x3c, 0xcb, 0x6b, 0xb9, 0xf5, 0x4e, 0x53,
+	0xc1, 0xa6, 0x5a, 0xc7, 0xfb, 0x23, 0x80, 0x86, 0x3a, 0x91, 0x2c, 0xc7, 0x87, 0x48, 0x24, 0x4b,
+	0xb7, 0xcc, 0x48, 0xae, 0xbf, 0x41, 0xe6, 0xb7, 0x7d, 0x83, 0xb0, 0x78, 0xf0, 0x9f, 0xe6, 0x27,
+	0x59, 0xa4, 0xff, 0x31, 0x6d, 0x6a, 0x23, 0x63, 0x9c, 0x45, 0xec, 0xa7, 0xbf, 0x02, 0x58, 0x0f,
+	0x73, 0xd2, 0x81, 0x07, 0xe3, 0xd1, 0x64, 0x32, 0xba, 0x18, 0x38, 0x7b, 0x58, 0x0a, 0x17, 0xa7,
+	0x93, 0x01, 0xd5, 0x15, 0x33, 0x1e, 0x5d, 0x62, 0xc5, 0x00, 0xb4, 0x86, 0xaf, 0xc6, 0xa3, 0xcb,
+	0x2f, 0x9d, 0xc6, 0x59, 0xff, 0x0f, 0x3f, 0x9e, 0x71, 0x39, 0x5f, 0x4c, 0x8f, 0xc3, 0x2c, 0x39,
+	0x89, 0x83, 0x82, 0x25, 0xac, 0x60, 0x27, 0x0a, 0xc5, 0xa7, 0x08, 0xe3, 0xa4, 0xfc, 0x1f, 0x4e,
+	0x5b, 0xea, 0x67, 0xf8, 0xb3, 0xff, 0x0d, 0x00, 0xc1, 0xae, 0xc1, 0xa1, 0x54, 0x0e, 0x00, 0x00,
 }
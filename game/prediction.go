@@ -0,0 +1,78 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+// In server authoritative games the client's own ship is Predicted: the client
+// simulates it straight away from the local controls, and sends those controls
+// to the host numbered by ShipControl.Seq.  The host applies them to its copy
+// of the ship, and sends back its state along with the last Seq it applied.
+// The client then resets its ship to that state, and replays the controls the
+// host hasn't applied yet on top of it.
+
+// maxUnacked is how many controls are kept for replaying, a few seconds at 60
+// frames a second.  If the host falls further behind than that, the oldest are
+// dropped and the ship will jump when the host catches up.
+const maxUnacked = 240
+
+type predictedInput struct {
+	control ShipControl
+	dt      float32
+}
+
+func (g *Game) recordInput(control ShipControl, dt float32) {
+	if len(g.unacked) == maxUnacked {
+		g.unacked = g.unacked[1:]
+	}
+	g.unacked = append(g.unacked, predictedInput{
+		control: control,
+		dt:      dt,
+	})
+}
+
+// viewTick is the host's tick which the client is showing the other entities
+// at, see interpolation.go.
+func (g *Game) viewTick() uint64 {
+	t := g.serverClock.clock - float64(g.InterpolationDelay/TickDt)
+	if t < 0 {
+		return 0
+	}
+	return uint64(t)
+}
+
+// reconcile replays the controls after ack on the predicted ship the iter is
+// pointing at, which has just been set to the host's state.
+func (g *Game) reconcile(i *Iter, ack uint32) {
+	for len(g.unacked) > 0 && g.unacked[0].control.Seq <= ack {
+		g.unacked = g.unacked[1:]
+	}
+
+	sc := ShipControlKey.Get(i)
+	current := *sc
+	for _, input := range g.unacked {
+		*sc = input.control
+		predictShip(i, input.dt)
+	}
+	*sc = current
+}
+
+// predictShip moves the ship the iter is pointing at by dt.  It must do the
+// same as the systems which move ships, in the same order.
+func predictShip(i *Iter, dt float32) {
+	steerShip(i, dt)
+	*RotKey.Get(i) += *SpinKey.Get(i) * dt
+	bound(i)
+	pull(i, dt)
+	PosKey.Get(i).AddEqual(MomentumKey.Get(i).Scale(dt))
+}
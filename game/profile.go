@@ -0,0 +1,181 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// profileWindow is how many ticks the histograms cover, 5 seconds at 60 ticks
+// a second.
+const profileWindow = 300
+
+// HistogramBuckets are the upper bounds of each histogram bucket.  Samples
+// larger than the last go into one final bucket.
+var HistogramBuckets = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2 * time.Millisecond,
+	4 * time.Millisecond,
+	8 * time.Millisecond,
+	16 * time.Millisecond,
+}
+
+// Profile is what a single tick spent its time on.
+type Profile struct {
+	Total   time.Duration
+	Systems []SystemTiming
+	// Bags is the number of entities in each bag at the end of the tick.
+	Bags []BagCount
+}
+
+type SystemTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+type BagCount struct {
+	// Components are the names of the components of the entities in the bag.
+	Components string
+	Count      int
+}
+
+// Histogram counts the samples from the last profileWindow ticks into
+// HistogramBuckets.
+type Histogram struct {
+	Counts  []int
+	samples []time.Duration
+	next    int
+}
+
+func newHistogram() *Histogram {
+	return &Histogram{
+		Counts: make([]int, len(HistogramBuckets)+1),
+	}
+}
+
+func bucketOf(d time.Duration) int {
+	return sort.Search(len(HistogramBuckets), func(i int) bool {
+		return d <= HistogramBuckets[i]
+	})
+}
+
+func (h *Histogram) add(d time.Duration) {
+	if len(h.samples) < profileWindow {
+		h.samples = append(h.samples, d)
+	} else {
+		h.Counts[bucketOf(h.samples[h.next])]--
+		h.samples[h.next] = d
+		h.next = (h.next + 1) % profileWindow
+	}
+	h.Counts[bucketOf(d)]++
+}
+
+// Max returns the longest sample in the window.
+func (h *Histogram) Max() time.Duration {
+	max := time.Duration(0)
+	for _, d := range h.samples {
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+func (h *Histogram) String() string {
+	b := &strings.Builder{}
+	for i, count := range h.Counts {
+		if count == 0 {
+			continue
+		}
+		if i < len(HistogramBuckets) {
+			fmt.Fprintf(b, "<=%v:%d ", HistogramBuckets[i], count)
+		} else {
+			fmt.Fprintf(b, ">%v:%d ", HistogramBuckets[i-1], count)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Profiler records how long each tick takes.
+type Profiler struct {
+	// Last is the profile of the most recent tick.
+	Last Profile
+
+	Total   *Histogram
+	Systems map[string]*Histogram
+}
+
+func newProfiler() *Profiler {
+	return &Profiler{
+		Total:   newHistogram(),
+		Systems: make(map[string]*Histogram),
+	}
+}
+
+func (p *Profiler) start() {
+	p.Last.Systems = p.Last.Systems[:0]
+	p.Last.Bags = p.Last.Bags[:0]
+}
+
+func (p *Profiler) system(name string, d time.Duration) {
+	p.Last.Systems = append(p.Last.Systems, SystemTiming{name, d})
+
+	h, ok := p.Systems[name]
+	if !ok {
+		h = newHistogram()
+		p.Systems[name] = h
+	}
+	h.add(d)
+}
+
+func (p *Profiler) finish(e *Entities, total time.Duration) {
+	p.Last.Total = total
+	p.Total.add(total)
+
+	for _, bag := range e.bags {
+		if bag.count > 0 {
+			p.Last.Bags = append(p.Last.Bags, BagCount{bag.name, bag.count})
+		}
+	}
+}
+
+// String describes the last tick and the histograms, eg for logging or a
+// debug overlay.
+func (p *Profiler) String() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "tick %v (max %v) [%v]\n", p.Last.Total, p.Total.Max(), p.Total)
+
+	for _, s := range p.Last.Systems {
+		h := p.Systems[s.Name]
+		fmt.Fprintf(b, "  %s %v (max %v)\n", s.Name, s.Duration, h.Max())
+	}
+
+	total := 0
+	for _, bag := range p.Last.Bags {
+		total += bag.Count
+	}
+	fmt.Fprintf(b, "entities %d\n", total)
+	for _, bag := range p.Last.Bags {
+		fmt.Fprintf(b, "  %d %s\n", bag.Count, bag.Components)
+	}
+
+	return b.String()
+}
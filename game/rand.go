@@ -0,0 +1,47 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"math/rand"
+)
+
+// randSource is a splitmix64 generator.  Unlike the sources in math/rand, its
+// whole state is one number, so it can be saved in snapshots.
+type randSource struct {
+	state uint64
+}
+
+func (s *randSource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+func (s *randSource) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (s *randSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func newRand(seed int64) (*rand.Rand, *randSource) {
+	src := &randSource{}
+	src.Seed(seed)
+	return rand.New(src), src
+}
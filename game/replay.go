@@ -0,0 +1,112 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/laremere/space-agon/game/pb"
+	"github.com/laremere/space-agon/game/protostream"
+)
+
+// Recorder saves everything a host game created by NewDeterministicGame is
+// given, so that the match can be replayed exactly with Replay.
+type Recorder struct {
+	stream *protostream.ProtoStream
+	g      *Game
+	mode   string
+}
+
+// NewRecorder starts a recording of g, which must not have been stepped yet.
+func NewRecorder(rw protostream.ReaderWriter, g *Game) (*Recorder, error) {
+	r := &Recorder{
+		stream: protostream.NewProtoStream(rw),
+		g:      g,
+	}
+	err := r.stream.Send(&pb.ReplayHeader{
+		Seed:                g.Seed,
+		ServerAuthoritative: g.ServerAuthoritative,
+		RollbackTicks:       int32(g.RollbackTicks()),
+		Rules:               g.Rules,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Record saves the input for a call to Step, so must be called before Step
+// clears the input's memos.
+func (r *Recorder) Record(input *Input) error {
+	step := &pb.ReplayStep{
+		Dt:    input.Dt,
+		Memos: input.Memos,
+	}
+	if mode := r.g.Mode.Name(); mode != r.mode {
+		r.mode = mode
+		step.GameMode = mode
+	}
+	return r.stream.Send(step)
+}
+
+// Replay runs a recording through a new host game.  afterStep, if not nil, is
+// called after every step with the memos the game sent.
+func Replay(rw protostream.ReaderWriter, afterStep func(g *Game, input *Input)) (*Game, error) {
+	stream := protostream.NewProtoStream(rw)
+
+	header := &pb.ReplayHeader{}
+	err := stream.Recv(header)
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewDeterministicGame(header.Seed)
+	g.ServerAuthoritative = header.ServerAuthoritative
+	g.Rules = header.Rules
+	if header.RollbackTicks > 0 {
+		g.EnableRollback(int(header.RollbackTicks))
+	}
+	input := NewInput()
+	input.IsHost = true
+
+	for {
+		step := &pb.ReplayStep{}
+		err := stream.Recv(step)
+		if err == io.EOF {
+			return g, nil
+		}
+		if err != nil {
+			return g, err
+		}
+
+		if step.GameMode != "" {
+			mode, ok := LookupGameMode(step.GameMode)
+			if !ok {
+				return g, fmt.Errorf("unknown game mode %s", step.GameMode)
+			}
+			g.Mode = mode
+		}
+
+		input.Dt = step.Dt
+		input.Memos = step.Memos
+		g.Step(input)
+
+		if afterStep != nil {
+			afterStep(g, input)
+		}
+		input.MemosOut = nil
+	}
+}
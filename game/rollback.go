@@ -0,0 +1,255 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/laremere/space-agon/game/pb"
+)
+
+// In rollback mode the host of a server authoritative game keeps a snapshot of
+// each of the last few ticks, along with the memos it applied.  Clients tag
+// their controls with the tick they were looking at when they sent them.  When
+// those controls arrive, the host rewinds to that tick, applies them there,
+// and resimulates back up to the present.  So a missile fired by a player with
+// a high ping is flown, and hits or misses, against the ships where that
+// player saw them.  For that the host creates missiles in the tick they're
+// fired, rather than when their memo comes back to it.  Explosions still go
+// through memos, so the damage a hit does is worked out in the present.
+//
+// Restoring a snapshot rewinds the next network id, so while rolling back nids
+// are handed out per tick instead: a resimulated tick is given the nids it
+// handed out before, in the same order, so entities clients already have keep
+// theirs, and any more it needs are new ones.
+//
+// Everything a tick sent the first time around has already gone out.  When a
+// resimulated tick sends a memo which the original didn't, such as a new hit,
+// it is sent as well.  Memos the original sent but the resimulation doesn't
+// can't be taken back, so clients can be left disagreeing with the host, eg
+// about a ship's health, until a later memo corrects them.  Tracks aren't
+// resent, as the next tick sends the corrected ones anyway.  Resimulated ticks
+// aren't profiled, so the profile only shows the ticks the game moved forward.
+
+type rollback struct {
+	frames []rollbackFrame
+	// current is the frame of the tick being run, while one is.
+	current *rollbackFrame
+}
+
+type rollbackFrame struct {
+	tick uint64
+	// snapshot is the state of the game before the tick ran.
+	snapshot []byte
+	// memos are those the tick applies, including late controls added since.
+	memos []*pb.Memo
+	// nids are those the tick has handed out, and usedNids how many of them the
+	// run of the tick in progress has.
+	nids     []uint64
+	usedNids int
+	// sent are the keys of the memos the tick has sent, see memoKey.
+	sent map[string]bool
+}
+
+// EnableRollback turns on rollback for up to the given number of ticks.  It
+// must be called before the game is first stepped, on a game which runs in
+// fixed ticks.
+func (g *Game) EnableRollback(ticks int) {
+	if g.FixedDt == 0 {
+		panic("Rollback needs a game which runs in fixed ticks")
+	}
+	g.rollback = &rollback{
+		frames: make([]rollbackFrame, ticks),
+	}
+}
+
+// RollbackTicks returns how many ticks the game can roll back, 0 if rollback
+// isn't enabled.
+func (g *Game) RollbackTicks() int {
+	if g.rollback == nil {
+		return 0
+	}
+	return len(g.rollback.frames)
+}
+
+// frame returns the frame for the tick, ok is false if it's too old or hasn't
+// run yet.
+func (r *rollback) frame(tick uint64) (f *rollbackFrame, ok bool) {
+	f = &r.frames[tick%uint64(len(r.frames))]
+	return f, tick != 0 && f.tick == tick
+}
+
+// recordTick runs the next tick, keeping what is needed to roll it back.
+func (g *Game) recordTick(input *Input) {
+	snapshot, err := g.Snapshot()
+	if err != nil {
+		// There are never pending commands between ticks.
+		panic(err)
+	}
+
+	f, _ := g.rollback.frame(g.Tick + 1)
+	*f = rollbackFrame{
+		tick:     g.Tick + 1,
+		snapshot: snapshot,
+		memos:    input.Memos,
+		sent:     make(map[string]bool),
+	}
+
+	sent := len(input.MemosOut)
+	g.rollback.current = f
+	g.tick(input)
+	g.rollback.current = nil
+	keys := memoKeys{}
+	for _, memo := range input.MemosOut[sent:] {
+		if !isTrack(memo) {
+			f.sent[keys.key(memo)] = true
+		}
+	}
+}
+
+// rewind takes late controls out of the pending memos and adds them to the
+// ticks they were meant for, then resimulates from the earliest of those.
+func (g *Game) rewind(input *Input) {
+	earliest := uint64(0)
+	pending := g.pendingMemos[:0]
+
+	for _, memo := range g.pendingMemos {
+		if a, ok := memo.Actual.(*pb.Memo_ShipControlTrack); ok {
+			tick := a.ShipControlTrack.Tick
+			if f, ok := g.rollback.frame(tick); ok && tick <= g.Tick {
+				f.memos = append(f.memos, memo)
+				if earliest == 0 || tick < earliest {
+					earliest = tick
+				}
+				continue
+			}
+		}
+		pending = append(pending, memo)
+	}
+	g.pendingMemos = pending
+
+	if earliest != 0 {
+		g.resimulate(earliest, input)
+	}
+}
+
+// resimulate restores the game to before the tick from, and runs it back up to
+// the current tick.
+func (g *Game) resimulate(from uint64, input *Input) {
+	end := g.Tick
+	accumulated, pending := g.accumulated, g.pendingMemos
+	memosOut := input.MemosOut
+
+	nextNid := g.NextNetworkId
+
+	f, _ := g.rollback.frame(from)
+	err := g.restore(f.snapshot)
+	if err != nil {
+		panic(err)
+	}
+	g.NextNetworkId = nextNid
+
+	for g.Tick < end {
+		f, _ := g.rollback.frame(g.Tick + 1)
+		f.snapshot, err = g.Snapshot()
+		if err != nil {
+			panic(err)
+		}
+
+		input.Dt = g.FixedDt
+		input.Memos = f.memos
+		input.MemosOut = nil
+		f.usedNids = 0
+		g.rollback.current = f
+		g.runTick(input, nil)
+		g.rollback.current = nil
+
+		keys := memoKeys{}
+		for _, memo := range input.MemosOut {
+			if isTrack(memo) {
+				continue
+			}
+			key := keys.key(memo)
+			if !f.sent[key] {
+				f.sent[key] = true
+				memosOut = append(memosOut, memo)
+			}
+		}
+	}
+
+	g.accumulated, g.pendingMemos = accumulated, pending
+	input.Memos = nil
+	input.MemosOut = memosOut
+}
+
+// nid hands out the next nid for the current tick, see the top of this file.
+func (r *rollback) nid(g *Game) uint64 {
+	f := r.current
+	if f.usedNids == len(f.nids) {
+		f.nids = append(f.nids, g.NextNetworkId)
+		g.NextNetworkId++
+	}
+	f.usedNids++
+	return f.nids[f.usedNids-1]
+}
+
+func isTrack(memo *pb.Memo) bool {
+	switch memo.Actual.(type) {
+	case *pb.Memo_Tracks, *pb.Memo_ShipControlTrack:
+		return true
+	}
+	return false
+}
+
+// memoKeys numbers the memos one run of a tick sends which have the same
+// memoKey, so that a tick which sends two explosions is known to have sent
+// both.
+type memoKeys map[string]int
+
+func (k memoKeys) key(memo *pb.Memo) string {
+	key := memoKey(memo)
+	k[key]++
+	return fmt.Sprint(key, " #", k[key])
+}
+
+// memoKey identifies a memo sent by a tick.  Spawns are identified by their
+// network id alone, everyone has already created the original entity, so a
+// resimulated spawn which differs slightly mustn't create a second one.
+// Explosions and damage are likewise identified by what exploded or was hit,
+// and lasers by the tick alone, not by positions and amounts which a
+// resimulation can change slightly.
+func memoKey(memo *pb.Memo) string {
+	switch a := memo.Actual.(type) {
+	case *pb.Memo_SpawnMissile:
+		return fmt.Sprint("missile ", a.SpawnMissile.Nid)
+	case *pb.Memo_SpawnShip:
+		return fmt.Sprint("ship ", a.SpawnShip.Nid)
+	case *pb.Memo_SpawnMine:
+		return fmt.Sprint("mine ", a.SpawnMine.Nid)
+	case *pb.Memo_SpawnExplosion:
+		return fmt.Sprint("explosion ", a.SpawnExplosion.Nid)
+	case *pb.Memo_DamageEvent:
+		return fmt.Sprint("damage ", a.DamageEvent.Nid)
+	case *pb.Memo_LaserBeam:
+		return "laser"
+	}
+
+	b, err := proto.Marshal(memo)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"math"
+	"testing"
+
+	"github.com/laremere/space-agon/game/pb"
+)
+
+// TestRollbackLateFire fires a missile across the path of a passing ship,
+// timed to hit it.  The controls which fire it reach the host late, and with
+// rollback it still hits, as it's fired in the tick the controls were sent
+// for.
+func TestRollbackLateFire(t *testing.T) {
+	const lag = 20
+
+	// fire runs a host where player 1 fires at player 2, with the controls
+	// arriving the given number of ticks late, and returns whether player 2's
+	// ship was hit.
+	fire := func(rollback bool, late int) bool {
+		g := NewDeterministicGame(1)
+		g.ServerAuthoritative = true
+		if rollback {
+			g.EnableRollback(30)
+		}
+		input := NewInput()
+		input.IsHost = true
+		for cid := int64(1); cid <= 2; cid++ {
+			input.SendTo(0, &pb.RegisterPlayer{
+				Cid: cid,
+			})
+		}
+
+		var shooter, target uint64
+		var controls [][]*pb.Memo
+		hit := false
+		for tick := 0; tick < 120; tick++ {
+			input.Memos = toHost(input.MemosOut)
+			input.MemosOut = nil
+
+			switch {
+			case tick == 5:
+				shooter, target = setUpShot(t, g)
+			case tick >= 10 && tick < 12:
+				// Fire for one tick, from the tick after this step's.
+				controls = append(controls, []*pb.Memo{{
+					Recipient: &pb.Memo_To{To: 0},
+					Actual: &pb.Memo_ShipControlTrack{
+						ShipControlTrack: &pb.ShipControlTrack{
+							Nid:  shooter,
+							Fire: tick == 10,
+							Seq:  uint32(tick),
+							Tick: g.Tick + 1,
+						},
+					},
+				}})
+			}
+			if tick >= 10+late && len(controls) > 0 {
+				input.Memos = append(input.Memos, controls[0]...)
+				controls = controls[1:]
+			}
+
+			input.Dt = TickDt
+			g.Step(input)
+
+			for _, memo := range input.MemosOut {
+				if a, ok := memo.Actual.(*pb.Memo_DamageEvent); ok && a.DamageEvent.Nid == target && target != 0 {
+					hit = true
+				}
+			}
+		}
+		return hit
+	}
+
+	if !fire(true, 0) {
+		t.Fatal("Missile fired on time missed")
+	}
+	if fire(false, lag) {
+		t.Fatal("Missile fired late without rollback hit, so the test shows nothing")
+	}
+	if !fire(true, lag) {
+		t.Error("Missile fired late with rollback missed")
+	}
+}
+
+// setUpShot puts player 1's ship still, pointing up, and player 2's ship
+// crossing in front of it, and returns their nids.
+func setUpShot(t *testing.T, g *Game) (shooter uint64, target uint64) {
+	i := g.E.NewIter()
+	i.Require(AuthorityKey)
+	i.Require(NetworkIdKey)
+	i.Require(PosKey)
+	i.Require(MomentumKey)
+	i.Require(RotKey)
+	i.Require(SpinKey)
+	for i.Next() {
+		*SpinKey.Get(i) = 0
+		switch *AuthorityKey.Get(i) {
+		case 1:
+			shooter = *NetworkIdKey.Get(i)
+			*PosKey.Get(i) = Vec2{20, 0}
+			*MomentumKey.Get(i) = Vec2{}
+			*RotKey.Get(i) = math.Pi / 2
+		case 2:
+			target = *NetworkIdKey.Get(i)
+			*PosKey.Get(i) = Vec2{14.6, 6}
+			*MomentumKey.Get(i) = Vec2{12, 0}
+			*RotKey.Get(i) = 0
+		}
+	}
+	if shooter == 0 || target == 0 {
+		t.Fatal("Ships weren't spawned")
+	}
+	return shooter, target
+}
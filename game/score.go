@@ -0,0 +1,130 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/laremere/space-agon/game/pb"
+)
+
+// The host keeps score.  Every explosion carries the cid of the player who
+// caused it, taken from the missile which set it off, and a ship destroyed by
+// it is credited to them.  Ships which fly into the sun, or are destroyed by
+// their own missiles, are suicides.
+
+// score returns the score of the player, adding them to the scoreboard if they
+// aren't on it yet.  Scores are kept in order of cid.
+func (g *Game) score(cid int64) *pb.Score {
+	scores := g.Scoreboard.Scores
+	j := sort.Search(len(scores), func(k int) bool {
+		return scores[k].Cid >= cid
+	})
+	if j < len(scores) && scores[j].Cid == cid {
+		return scores[j]
+	}
+
+	s := &pb.Score{Cid: cid}
+	scores = append(scores, nil)
+	copy(scores[j+1:], scores[j:])
+	scores[j] = s
+	g.Scoreboard.Scores = scores
+	return s
+}
+
+// playerJoined puts a player on the scoreboard when they first ask for a ship,
+// and returns whether the game mode lets them have one.
+func (g *Game) playerJoined(register *pb.RegisterPlayer, input *Input) bool {
+	before := len(g.Scoreboard.Scores)
+	g.score(register.Cid)
+	allowed := g.Mode.OnPlayerRegistered(g, register, input)
+	if len(g.Scoreboard.Scores) != before {
+		g.sendScoreboard(input)
+	}
+	return allowed
+}
+
+// playerLeft marks a player who has disconnected as departed, so that they no
+// longer count towards the players in the match.
+func (g *Game) playerLeft(cid int64, input *Input) {
+	for _, s := range g.Scoreboard.Scores {
+		if s.Cid == cid {
+			s.Departed = true
+			g.sendScoreboard(input)
+			return
+		}
+	}
+}
+
+// players returns how many players on the scoreboard haven't left.
+func (g *Game) players() int {
+	players := 0
+	for _, s := range g.Scoreboard.Scores {
+		if !s.Departed {
+			players++
+		}
+	}
+	return players
+}
+
+// shipDestroyed scores the destruction of the entity the iter is pointing at,
+// if it's a player's ship, by the player causedBy.
+func (g *Game) shipDestroyed(i *Iter, causedBy int64, input *Input) {
+	if !input.IsHost || !g.scoring() {
+		return
+	}
+	authority := AuthorityKey.Get(i)
+	if authority == nil {
+		return
+	}
+
+	g.Mode.OnKill(g, *authority, causedBy, input)
+	g.sendScoreboard(input)
+}
+
+// scoreKill is how most game modes score the ship of victim being destroyed by
+// killer.
+func (g *Game) scoreKill(victim int64, killer int64) {
+	v := g.score(victim)
+	v.Deaths++
+	if killer == 0 || killer == victim {
+		v.Suicides++
+	} else {
+		g.score(killer).Kills++
+	}
+}
+
+// Points is what a player is ranked by: kills, less suicides.
+func Points(s *pb.Score) int32 {
+	return s.Kills - s.Suicides
+}
+
+func (g *Game) sendScoreboard(input *Input) {
+	// The scoreboard keeps changing after being sent, so send a copy.
+	input.BroadcastAll(proto.Clone(g.Scoreboard).(*pb.Scoreboard))
+}
+
+// causedBy returns who is credited with the explosion of the entity the iter is
+// pointing at.
+func causedBy(i *Iter) int64 {
+	if details := MissileDetailsKey.Get(i); details != nil {
+		return details.OwnerCid
+	}
+	if details := MineDetailsKey.Get(i); details != nil {
+		return details.OwnerCid
+	}
+	return 0
+}
@@ -0,0 +1,423 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/laremere/space-agon/game/pb"
+)
+
+// A snapshot is the entire state of a Game, encoded so that it can be restored
+// into a new Game.  Components are matched up by name, so a snapshot can be
+// restored by a build which registers its components in a different order.
+// Component types are written with encoding/binary, so must be fixed size.
+//
+// The format is, all little endian:
+//
+//	magic, version
+//	game fields
+//	pending memos: count, then each as a length and the encoded proto
+//	scoreboard: length, then the encoded proto
+//	entity slots: count, then each generation; free slots: count, then each
+//	bags: count, then for each bag:
+//	  components: count, then each name
+//	  entity count
+//	  the data of each component with a column, in the order of the names
+
+const snapshotMagic = "SPACEAGON"
+const snapshotVersion = uint32(7)
+
+var byteOrder = binary.LittleEndian
+
+// Snapshot encodes the state of the game.  It must be called between calls to
+// Step, when there are no pending Commands.
+func (g *Game) Snapshot() ([]byte, error) {
+	if len(g.E.Commands.pending) > 0 {
+		return nil, errors.New("can't snapshot with pending commands")
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString(snapshotMagic)
+	writeValue(b, snapshotVersion)
+
+	for _, v := range g.snapshotFields() {
+		writeValue(b, v)
+	}
+
+	writeValue(b, uint32(len(g.pendingMemos)))
+	for _, memo := range g.pendingMemos {
+		m, err := proto.Marshal(memo)
+		if err != nil {
+			return nil, err
+		}
+		writeValue(b, uint32(len(m)))
+		b.Write(m)
+	}
+
+	scoreboard, err := proto.Marshal(g.Scoreboard)
+	if err != nil {
+		return nil, err
+	}
+	writeValue(b, uint32(len(scoreboard)))
+	b.Write(scoreboard)
+
+	err = g.E.write(b)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// RestoreGame creates a game from a snapshot created by Game.Snapshot.
+func RestoreGame(snapshot []byte) (*Game, error) {
+	g := NewGame()
+	err := g.restore(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// restore replaces the state of g with the snapshot.  Settings which aren't
+// part of the snapshot, like the systems, are kept.
+func (g *Game) restore(snapshot []byte) error {
+	r := bytes.NewReader(snapshot)
+
+	magic := make([]byte, len(snapshotMagic))
+	_, err := io.ReadFull(r, magic)
+	if err != nil || string(magic) != snapshotMagic {
+		return errors.New("not a snapshot")
+	}
+	var version uint32
+	err = readValue(r, &version)
+	if err != nil {
+		return err
+	}
+	if version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", version)
+	}
+
+	for _, v := range g.snapshotFields() {
+		err = readValue(r, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	var memos uint32
+	err = readValue(r, &memos)
+	if err != nil {
+		return err
+	}
+	g.pendingMemos = nil
+	for ; memos > 0; memos-- {
+		var length uint32
+		err = readValue(r, &length)
+		if err != nil {
+			return err
+		}
+		err = checkLength(r, int64(length), 1)
+		if err != nil {
+			return err
+		}
+		m := make([]byte, length)
+		_, err = io.ReadFull(r, m)
+		if err != nil {
+			return err
+		}
+		memo := &pb.Memo{}
+		err = proto.Unmarshal(m, memo)
+		if err != nil {
+			return err
+		}
+		g.pendingMemos = append(g.pendingMemos, memo)
+	}
+
+	var length uint32
+	err = readValue(r, &length)
+	if err != nil {
+		return err
+	}
+	err = checkLength(r, int64(length), 1)
+	if err != nil {
+		return err
+	}
+	scoreboard := make([]byte, length)
+	_, err = io.ReadFull(r, scoreboard)
+	if err != nil {
+		return err
+	}
+	g.Scoreboard = &pb.Scoreboard{}
+	err = proto.Unmarshal(scoreboard, g.Scoreboard)
+	if err != nil {
+		return err
+	}
+
+	g.E = newEntities()
+	err = g.E.read(r)
+	if err != nil {
+		return err
+	}
+
+	g.NetworkIds = make(map[uint64]EntityID)
+	i := g.E.NewIter()
+	i.Require(NetworkIdKey)
+	i.Require(LookupKey)
+	for i.Next() {
+		g.NetworkIds[*NetworkIdKey.Get(i)] = LookupKey.Get(i)
+	}
+	g.spatial.stale = true
+
+	return nil
+}
+
+// snapshotFields are the fixed size fields of Game which are saved, in order.
+func (g *Game) snapshotFields() []interface{} {
+	return []interface{}{
+		&g.initialized,
+		&g.NextNetworkId,
+		&g.ControlledShip,
+		&g.timeDead,
+		&g.randSource.state,
+		&g.Tick,
+		&g.FixedDt,
+		&g.accumulated,
+		&g.ServerAuthoritative,
+		&g.Match.Phase,
+		&g.Match.TimeLeft,
+	}
+}
+
+func (e *Entities) write(w *bytes.Buffer) error {
+	writeValue(w, uint32(len(e.slots)))
+	for _, slot := range e.slots {
+		writeValue(w, slot.generation)
+	}
+	writeValue(w, uint32(len(e.freeSlots)))
+	writeValue(w, e.freeSlots)
+
+	bags := 0
+	for _, bag := range e.bags {
+		if bag.count > 0 {
+			bags++
+		}
+	}
+	writeValue(w, uint32(bags))
+
+	for _, bag := range e.bags {
+		if bag.count == 0 {
+			continue
+		}
+
+		var ids []compID
+		for id := range registered {
+			if inRequirement(&bag.compsKey, compID(id)) {
+				ids = append(ids, compID(id))
+			}
+		}
+
+		writeValue(w, uint16(len(ids)))
+		for _, id := range ids {
+			writeString(w, registered[id].name)
+		}
+		writeValue(w, uint32(bag.count))
+		for _, id := range ids {
+			if c := bag.cols[id]; c != nil {
+				err := c.write(w)
+				if err != nil {
+					return fmt.Errorf("error writing component %s: %w", registered[id].name, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// read loads entities written by write, it must be called on empty Entities.
+func (e *Entities) read(r *bytes.Reader) error {
+	var slots uint32
+	err := readValue(r, &slots)
+	if err != nil {
+		return err
+	}
+	err = checkLength(r, int64(slots), 4)
+	if err != nil {
+		return err
+	}
+	e.slots = make([]entitySlot, slots)
+	for i := range e.slots {
+		err = readValue(r, &e.slots[i].generation)
+		if err != nil {
+			return err
+		}
+	}
+
+	var free uint32
+	err = readValue(r, &free)
+	if err != nil {
+		return err
+	}
+	err = checkLength(r, int64(free), 4)
+	if err != nil {
+		return err
+	}
+	e.freeSlots = make([]uint32, free)
+	err = readValue(r, e.freeSlots)
+	if err != nil {
+		return err
+	}
+
+	var bags uint32
+	err = readValue(r, &bags)
+	if err != nil {
+		return err
+	}
+	for ; bags > 0; bags-- {
+		var count uint16
+		err = readValue(r, &count)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]compID, count)
+		key := compsKey{}
+		for n := range ids {
+			name, err := readString(r)
+			if err != nil {
+				return err
+			}
+			id, ok := lookupComponent(name)
+			if !ok {
+				return fmt.Errorf("snapshot has unknown component %s", name)
+			}
+			ids[n] = id
+			key.set(id)
+		}
+
+		var entities uint32
+		err = readValue(r, &entities)
+		if err != nil {
+			return err
+		}
+
+		i := e.bagIndex(&key)
+		bag := e.bags[i]
+		bag.count = int(entities)
+		for _, id := range ids {
+			if c := bag.cols[id]; c != nil {
+				err = c.read(r, i, bag.count)
+				if err != nil {
+					return fmt.Errorf("error reading component %s: %w", registered[id].name, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Column[T]) write(w io.Writer) error {
+	return binary.Write(w, byteOrder, c.data)
+}
+
+func (c *Column[T]) read(r *bytes.Reader, i int, count int) error {
+	var zero T
+	err := checkLength(r, int64(count), binary.Size(zero))
+	if err != nil {
+		return err
+	}
+	c.data = make([]T, count)
+	return binary.Read(r, byteOrder, c.data)
+}
+
+func (c *lookupColumn) write(w io.Writer) error {
+	return binary.Write(w, byteOrder, c.ids)
+}
+
+func (c *lookupColumn) read(r *bytes.Reader, i int, count int) error {
+	err := checkLength(r, int64(count), binary.Size(EntityID(0)))
+	if err != nil {
+		return err
+	}
+	c.ids = make([]EntityID, count)
+	err = binary.Read(r, byteOrder, c.ids)
+	if err != nil {
+		return err
+	}
+	for j, id := range c.ids {
+		slot, ok := c.e.slot(id)
+		if !ok {
+			return fmt.Errorf("snapshot has entity with stale id %d", id)
+		}
+		slot.i = i
+		slot.j = j
+	}
+	return nil
+}
+
+func lookupComponent(name string) (compID, bool) {
+	for id, info := range registered {
+		if info.name == name {
+			return compID(id), true
+		}
+	}
+	return 0, false
+}
+
+// writeValue can't fail for the fixed size values it's used with, because
+// writing to a bytes.Buffer never fails.
+func writeValue(w *bytes.Buffer, v interface{}) {
+	err := binary.Write(w, byteOrder, v)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func writeString(w *bytes.Buffer, s string) {
+	writeValue(w, uint16(len(s)))
+	w.WriteString(s)
+}
+
+// checkLength returns an error if the rest of the snapshot is too short to hold
+// count values of size bytes, so that a corrupt length can't make restore
+// allocate more than the snapshot could fill.
+func checkLength(r *bytes.Reader, count int64, size int) error {
+	if count*int64(size) > int64(r.Len()) {
+		return fmt.Errorf("snapshot is too short for %d values of %d bytes", count, size)
+	}
+	return nil
+}
+
+func readValue(r io.Reader, v interface{}) error {
+	return binary.Read(r, byteOrder, v)
+}
+
+func readString(r io.Reader) (string, error) {
+	var length uint16
+	err := readValue(r, &length)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, length)
+	_, err = io.ReadFull(r, b)
+	return string(b), err
+}
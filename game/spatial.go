@@ -0,0 +1,106 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"math"
+)
+
+// spatialCellSize is the width of each grid cell.  It is a little larger than
+// the explosion radius, which is the most common query, so those only need to
+// look at a few cells.
+const spatialCellSize = ExplosionRadius * 2
+
+type spatialCell [2]int32
+
+type spatialEntry struct {
+	id  EntityID
+	pos Vec2
+}
+
+// spatialIndex is a uniform grid over every entity with a Pos and a Lookup,
+// so that finding the entities near a point doesn't need to check them all.
+// Particles don't have a Lookup, so they stay out of the index.
+type spatialIndex struct {
+	cells map[spatialCell][]spatialEntry
+	// stale is set whenever entities may have moved, been created, or been
+	// removed since the index was built.  The index is rebuilt on the next
+	// query.
+	stale bool
+}
+
+func newSpatialIndex() *spatialIndex {
+	return &spatialIndex{
+		cells: make(map[spatialCell][]spatialEntry),
+		stale: true,
+	}
+}
+
+func cellOf(pos Vec2) spatialCell {
+	return spatialCell{
+		int32(math.Floor(float64(pos[0] / spatialCellSize))),
+		int32(math.Floor(float64(pos[1] / spatialCellSize))),
+	}
+}
+
+func (s *spatialIndex) rebuild(e *Entities) {
+	for cell, entries := range s.cells {
+		if len(entries) == 0 {
+			delete(s.cells, cell)
+		} else {
+			s.cells[cell] = entries[:0]
+		}
+	}
+
+	i := e.NewIter()
+	i.Require(PosKey)
+	i.Require(LookupKey)
+	for i.Next() {
+		pos := *PosKey.Get(i)
+		cell := cellOf(pos)
+		s.cells[cell] = append(s.cells[cell], spatialEntry{
+			id:  LookupKey.Get(i),
+			pos: pos,
+		})
+	}
+
+	s.stale = false
+}
+
+// QueryRadius returns the ids of all entities with a Pos and a Lookup which are
+// less than r away from pos.
+func (g *Game) QueryRadius(pos Vec2, r float32) []EntityID {
+	s := g.spatial
+	if s.stale {
+		s.rebuild(g.E)
+	}
+
+	var found []EntityID
+
+	min := cellOf(pos.Sub(Vec2{r, r}))
+	max := cellOf(pos.Add(Vec2{r, r}))
+	for x := min[0]; x <= max[0]; x++ {
+		for y := min[1]; y <= max[1]; y++ {
+			for _, entry := range s.cells[spatialCell{x, y}] {
+				diff := pos.Sub(entry.pos)
+				if diff.Length() < r {
+					found = append(found, entry.id)
+				}
+			}
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+// Role is a set of the jobs a game instance has, taken from its Input.
+type Role uint8
+
+const (
+	RoleHost Role = 1 << iota
+	RoleRendered
+	RolePlayer
+)
+
+func (i *Input) roles() Role {
+	r := Role(0)
+	if i.IsHost {
+		r |= RoleHost
+	}
+	if i.IsRendered {
+		r |= RoleRendered
+	}
+	if i.IsPlayer {
+		r |= RolePlayer
+	}
+	return r
+}
+
+// System is one step of the simulation.
+type System struct {
+	Name string
+	// Requires are the roles an instance must all have for the system to run.
+	Requires Role
+	// DisabledFor skips the system on instances with any of these roles.
+	DisabledFor Role
+	// Disabled skips the system everywhere.
+	Disabled bool
+
+	Run func(g *Game, input *Input)
+}
+
+func (s *System) runsFor(r Role) bool {
+	return !s.Disabled && s.Requires&r == s.Requires && s.DisabledFor&r == 0
+}
+
+// defaultSystems returns the systems of the game, in the order they run.
+func defaultSystems() []*System {
+	return []*System{
+		{Name: "input-apply", Run: (*Game).applyInput},
+		{Name: "memo-apply", Run: (*Game).applyMemos},
+		{Name: "initialize", Run: (*Game).initialize},
+		{Name: "match", Run: (*Game).runMatch},
+		{Name: "respawn", Requires: RolePlayer, Run: (*Game).respawn},
+		{Name: "bots", Requires: RoleHost, Run: (*Game).flyBots},
+		{Name: "sun-particles", Requires: RoleRendered, Run: (*Game).spawnSunParticles},
+		{Name: "timed-destroy", Run: (*Game).timedDestroy},
+		{Name: "timed-explode", Run: (*Game).timedExplode},
+		{Name: "sun-explode", Run: (*Game).sunExplode},
+		{Name: "missile-collision", Run: (*Game).missileCollision},
+		{Name: "mine-trigger", Run: (*Game).triggerMines},
+		{Name: "shield-regen", Run: (*Game).regenerateShields},
+		{Name: "particle-sun-delete", Run: (*Game).particleSunDelete},
+		{Name: "ship-controls", Run: (*Game).shipControls},
+		{Name: "ship-particles", Requires: RoleRendered, Run: (*Game).spawnShipParticles},
+		{Name: "missile-homing", Run: (*Game).steerMissiles},
+		{Name: "missile-thrust", Run: (*Game).missileThrust},
+		{Name: "missile-particles", Requires: RoleRendered, Run: (*Game).spawnMissileParticles},
+		{Name: "spin", Run: (*Game).spin},
+		{Name: "bound-location", Run: (*Game).boundLocation},
+		{Name: "gravity", Run: (*Game).gravity},
+		{Name: "integrate", Run: (*Game).integrate},
+		{Name: "interpolate", Requires: RoleRendered, Run: (*Game).interpolate},
+		{Name: "frame-end-delete", Run: (*Game).frameEndDelete},
+		{Name: "net-transmit", Run: (*Game).netTransmit},
+	}
+}
+
+// System returns the system with the given name, or nil if there isn't one.
+func (g *Game) System(name string) *System {
+	for _, s := range g.Systems {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// AddSystem inserts s to run immediately after the system named after.  If after
+// is empty, s runs first.
+func (g *Game) AddSystem(after string, s *System) {
+	if g.System(s.Name) != nil {
+		panic("Duplicate system name: " + s.Name)
+	}
+
+	index := 0
+	if after != "" {
+		index = -1
+		for j, other := range g.Systems {
+			if other.Name == after {
+				index = j + 1
+			}
+		}
+		if index == -1 {
+			panic("No system named " + after)
+		}
+	}
+
+	g.Systems = append(g.Systems, nil)
+	copy(g.Systems[index+1:], g.Systems[index:])
+	g.Systems[index] = s
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+// Ships, and the missiles they fire, are on the team the game mode put their
+// player on, kept on the scoreboard.  Team 0 is no team.  Teammates spawn
+// near each other, and can't damage each other unless the match rules allow
+// friendly fire.  A player's own missiles can always damage them.
+
+// teamOf returns the team of the player.
+func (g *Game) teamOf(cid int64) int32 {
+	for _, s := range g.Scoreboard.Scores {
+		if s.Cid == cid {
+			return s.Team
+		}
+	}
+	return 0
+}
+
+// harmless returns whether something on team a can't damage something on team
+// b.
+func (g *Game) harmless(a int32, b int32) bool {
+	return a != 0 && a == b && g.Rules != nil && !g.Rules.FriendlyFire
+}
+
+// ownedBy returns whether the entity the iter is pointing at belongs to the
+// player.
+func (g *Game) ownedBy(i *Iter, cid int64) bool {
+	authority := AuthorityKey.Get(i)
+	return authority != nil && *authority == cid
+}
@@ -0,0 +1,205 @@
+// Copyright 2018 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// THIS FILE IS GENERATED, DO NOT EDIT
+// THIS FILE IS GENERATED, DO NOT EDIT
+// THIS FILE IS GENERATED, DO NOT EDIT
+// THIS FILE IS GENERATED, DO NOT EDIT
+// THIS FILE IS GENERATED, DO NOT EDIT
+// THIS FILE IS GENERATED, DO NOT EDIT
+// THIS FILE IS GENERATED, DO NOT EDIT
+// THIS FILE IS GENERATED, DO NOT EDIT
+// THIS FILE IS GENERATED, DO NOT EDIT
+// THIS FILE IS GENERATED, DO NOT EDIT
+// THIS FILE IS GENERATED, DO NOT EDIT
+// If you want to define new components, edit game/generation/components.json
+// Then run: go generate github.com/laremere/space-agon/game/generation
+
+package game
+
+import (
+	"github.com/laremere/space-agon/game/pb"
+)
+
+var rotQuantization = quantization{
+	bits: 16,
+	min:  0,
+	max:  6.2831855,
+	wrap: true,
+}
+
+// interpolatedKeys are the replicated components which NetworkReceive entities
+// smooth between tracks.
+var interpolatedKeys = []CompKey{
+	PosKey,
+	RotKey,
+}
+
+// interpolated holds the tracks received for an entity's interpolated
+// components, see interpolation.go.
+type interpolated struct {
+	interpolationClock
+	pos interpolationBuffer[Vec2]
+	rot interpolationBuffer[float32]
+}
+
+// apply sets each interpolated component of the entity the iter is pointing at
+// to its value at tick t.  Components with no track after t are left alone,
+// for the rest of the game to extrapolate.
+func (in *interpolated) apply(i *Iter, t float64) {
+	if v, ok := in.pos.at(t, lerpVec2); ok {
+		*PosKey.Get(i) = v
+	}
+	if v, ok := in.rot.at(t, rotQuantization.lerp); ok {
+		*RotKey.Get(i) = v
+	}
+}
+
+// transmitTracks sends the replicated components of every entity with
+// NetworkTransmit to everyone else, if there are any.
+func (g *Game) transmitTracks(input *Input) {
+	tracks := &pb.Tracks{
+		Tick: g.Tick,
+	}
+
+	{
+		t := &pb.PosTracks{}
+
+		i := g.E.NewIter()
+		i.Require(PosKey)
+		i.Require(NetworkTransmitKey)
+		i.Require(NetworkIdKey)
+
+		for i.Next() {
+			t.Nid = append(t.Nid, *NetworkIdKey.Get(i))
+			v := *PosKey.Get(i)
+			t.X = append(t.X, v[0])
+			t.Y = append(t.Y, v[1])
+		}
+
+		tracks.Pos = t
+	}
+
+	{
+		t := &pb.MomentumTracks{}
+
+		i := g.E.NewIter()
+		i.Require(MomentumKey)
+		i.Require(NetworkTransmitKey)
+		i.Require(NetworkIdKey)
+
+		for i.Next() {
+			t.Nid = append(t.Nid, *NetworkIdKey.Get(i))
+			v := *MomentumKey.Get(i)
+			t.X = append(t.X, v[0])
+			t.Y = append(t.Y, v[1])
+		}
+
+		tracks.Momentum = t
+	}
+
+	{
+		t := &pb.RotTracks{}
+
+		i := g.E.NewIter()
+		i.Require(RotKey)
+		i.Require(NetworkTransmitKey)
+		i.Require(NetworkIdKey)
+
+		for i.Next() {
+			t.Nid = append(t.Nid, *NetworkIdKey.Get(i))
+			v := *RotKey.Get(i)
+			t.Value = append(t.Value, rotQuantization.encode(v))
+		}
+
+		tracks.Rot = t
+	}
+
+	{
+		t := &pb.SpinTracks{}
+
+		i := g.E.NewIter()
+		i.Require(SpinKey)
+		i.Require(NetworkTransmitKey)
+		i.Require(NetworkIdKey)
+
+		for i.Next() {
+			t.Nid = append(t.Nid, *NetworkIdKey.Get(i))
+			v := *SpinKey.Get(i)
+			t.Value = append(t.Value, v)
+		}
+
+		tracks.Spin = t
+	}
+
+	// Games which transmit nothing, like clients of server authoritative games,
+	// don't send anything.
+	if len(tracks.Pos.Nid) == 0 &&
+		len(tracks.Momentum.Nid) == 0 &&
+		len(tracks.Rot.Nid) == 0 &&
+		len(tracks.Spin.Nid) == 0 {
+		return
+	}
+	input.BroadcastOthers(tracks)
+}
+
+// receiveTracks applies tracks sent by another game's transmitTracks.  Rendered
+// games buffer the interpolated components of NetworkReceive entities instead.
+func (g *Game) receiveTracks(input *Input, tracks *pb.Tracks) {
+	i := g.E.NewIter()
+
+	if t := tracks.Pos; t != nil {
+		for index, nid := range t.Nid {
+			if getNid(g, i, nid) {
+				v := Vec2{t.X[index], t.Y[index]}
+				if input.IsRendered && i.Has(NetworkReceiveKey) {
+					g.interpolation(i, tracks.Tick).pos.add(tracks.Tick, v)
+					continue
+				}
+				*PosKey.Get(i) = v
+			}
+		}
+	}
+
+	if t := tracks.Momentum; t != nil {
+		for index, nid := range t.Nid {
+			if getNid(g, i, nid) {
+				v := Vec2{t.X[index], t.Y[index]}
+				*MomentumKey.Get(i) = v
+			}
+		}
+	}
+
+	if t := tracks.Rot; t != nil {
+		for index, nid := range t.Nid {
+			if getNid(g, i, nid) {
+				v := rotQuantization.decode(t.Value[index])
+				if input.IsRendered && i.Has(NetworkReceiveKey) {
+					g.interpolation(i, tracks.Tick).rot.add(tracks.Tick, v)
+					continue
+				}
+				*RotKey.Get(i) = v
+			}
+		}
+	}
+
+	if t := tracks.Spin; t != nil {
+		for index, nid := range t.Nid {
+			if getNid(g, i, nid) {
+				v := t.Value[index]
+				*SpinKey.Get(i) = v
+			}
+		}
+	}
+}
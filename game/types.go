@@ -36,6 +36,7 @@ const (
 	SpriteStar
 	SpriteStarBit
 	SpriteExplosionFlash
+	SpriteMine
 )
 
 type Vec2 [2]float32
@@ -87,19 +88,96 @@ func (v Vec2) Dot(o Vec2) float32 {
 	return v[0]*o[0] + v[1]*o[1]
 }
 
-type Lookup [2]int
+// quantization maps floats between min and max onto integers of the given
+// number of bits, for sending over the network.
+type quantization struct {
+	bits int
+	min  float32
+	max  float32
+	// wrap values outside of the range back into it, instead of clamping them.
+	wrap bool
+}
+
+func (q quantization) steps() float32 {
+	return float32(uint64(1)<<q.bits - 1)
+}
+
+func (q quantization) encode(v float32) uint32 {
+	size := q.max - q.min
+	if q.wrap {
+		v = q.min + float32(math.Mod(float64(v-q.min), float64(size)))
+		if v < q.min {
+			v += size
+		}
+	}
+	if v <= q.min {
+		return 0
+	}
+	if v >= q.max {
+		return uint32(q.steps())
+	}
+	return uint32((v-q.min)/size*q.steps() + 0.5)
+}
 
-func (l *Lookup) Alive() bool {
-	return l != nil && (*l)[0] >= 0
+func (q quantization) decode(i uint32) float32 {
+	return q.min + float32(i)/q.steps()*(q.max-q.min)
+}
+
+// lerp interpolates from a to b, going the shorter way around if the range
+// wraps.
+func (q quantization) lerp(a, b float32, f float32) float32 {
+	d := b - a
+	if q.wrap {
+		d = float32(math.Remainder(float64(d), float64(q.max-q.min)))
+	}
+	return a + d*f
 }
 
 type ShipControl struct {
-	Up           bool
-	Down         bool
-	Left         bool
-	Right        bool
-	Fire         bool
+	Up        bool
+	Down      bool
+	Left      bool
+	Right     bool
+	Fire      bool
+	Secondary bool
+	// SwitchWeapon is set when the player asks to switch weapons, until the
+	// next tick switches them.
+	SwitchWeapon bool
 	FireCoolDown float32
+	// Seq is the number of the latest controls sent to the server by a predicted
+	// ship, or on the server the number of the latest controls applied.
+	Seq uint32
+}
+
+// Shield absorbs damage before Health does, and regenerates once the entity
+// hasn't been damaged for a while.
+type Shield struct {
+	Value float32
+	Max   float32
+	// SinceDamage is how long it has been since the entity was last damaged.
+	SinceDamage float32
+}
+
+// Weapon is what a ship fires with its secondary fire, see weapons.go.
+type Weapon struct {
+	Kind pb.WeaponKind
+	// Ammo is how many shots of each kind are left.
+	Ammo     [weaponKinds]int32
+	CoolDown float32
+}
+
+type MineDetails struct {
+	OwnerCid int64
+	// Arming is how long until the mine can be set off.
+	Arming float32
+}
+
+// BotPilot is a bot player, which flies its ship from the host, see bots.go.
+type BotPilot struct {
+	Cid  int64
+	Ship EntityID
+	// TimeDead is how long the bot has been waiting to respawn.
+	TimeDead float32
 }
 
 // TODO: Use?
@@ -112,5 +190,10 @@ type ExplosionDetails struct {
 }
 
 type MissileDetails struct {
-	Owner *Lookup
+	Owner EntityID
+	// OwnerCid is the player who fired the missile, who is credited with its
+	// kills.
+	OwnerCid int64
+	// Target is the ship a homing missile steers towards, or 0.
+	Target EntityID
 }
@@ -0,0 +1,440 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package game
+
+import (
+	"math"
+
+	"github.com/laremere/space-agon/game/pb"
+)
+
+// Ships fire missiles with their primary fire, as many as they like.  Their
+// secondary fire uses the kind of weapon selected on their Weapon, which has
+// limited ammo, refilled when the ship respawns.  Whoever flies the ship keeps
+// track of its ammo, and sends the host a FireWeapon for each shot.
+//
+// Lasers hit straight away, along a line in front of the ship.  The host works
+// out the first thing they hit, and tells everyone where to draw the beam.
+//
+// Mines are dropped behind the ship, and arm after a moment.  They explode
+// when a ship comes close, or after a while if none does.
+//
+// Homing missiles are missiles which the host gives a target when they're
+// fired, the nearest enemy ship in front of the shooter.  The target is sent
+// with the missile, so every game steers it the same way.
+
+const weaponKinds = 4
+
+type weaponSpec struct {
+	coolDown float32
+	// ammo is how many shots a ship starts with.
+	ammo int32
+}
+
+var weaponSpecs = [weaponKinds]weaponSpec{
+	pb.WeaponKind_MISSILE: {coolDown: 0.5},
+	pb.WeaponKind_LASER:   {coolDown: 1.5, ammo: 8},
+	pb.WeaponKind_MINE:    {coolDown: 1, ammo: 4},
+	pb.WeaponKind_HOMING:  {coolDown: 1, ammo: 6},
+}
+
+// secondaryWeapons are the kinds which secondary fire switches between.
+var secondaryWeapons = []pb.WeaponKind{
+	pb.WeaponKind_LASER,
+	pb.WeaponKind_MINE,
+	pb.WeaponKind_HOMING,
+}
+
+const (
+	LaserDamage = 45
+	// LaserRange is how far lasers reach, and laserHitRadius how close to
+	// something they must pass to hit it.
+	LaserRange     = 15
+	laserHitRadius = 0.6
+
+	mineArmTime = 1
+	// MineTriggerRadius is how close a ship must come to set off a mine.
+	MineTriggerRadius = 1.5
+	mineLifetime      = 30
+
+	// Homing missiles pick a target within homingRange, and within homingCone
+	// radians either side of where the shooter is pointing.
+	homingRange = 20
+	homingCone  = 0.5
+	// HomingTurnRate is the fastest, in radians per second, that homing
+	// missiles turn.
+	HomingTurnRate = 3
+)
+
+func newWeapon() Weapon {
+	w := Weapon{
+		Kind: secondaryWeapons[0],
+	}
+	for kind, spec := range weaponSpecs {
+		w.Ammo[kind] = spec.ammo
+	}
+	return w
+}
+
+func isSecondaryWeapon(kind pb.WeaponKind) bool {
+	for _, k := range secondaryWeapons {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// armed returns whether the entity the iter is pointing at is a ship with
+// everything needed to fire its weapons.  FireWeapon memos can name any nid.
+func armed(i *Iter) bool {
+	return i.Has(WeaponKey) && i.Has(AuthorityKey) && i.Has(TeamKey) &&
+		i.Has(PosKey) && i.Has(MomentumKey) && i.Has(RotKey) && i.Has(SpinKey) &&
+		i.Has(NetworkIdKey) && i.Has(LookupKey)
+}
+
+// switchWeapon selects the next secondary weapon.
+func (w *Weapon) switchWeapon() {
+	for j, kind := range secondaryWeapons {
+		if kind == w.Kind {
+			w.Kind = secondaryWeapons[(j+1)%len(secondaryWeapons)]
+			return
+		}
+	}
+	w.Kind = secondaryWeapons[0]
+}
+
+// fireWeapon switches the secondary weapon of the ship the iter is pointing at
+// if asked to, and fires it if it's wanted and ready.
+func (g *Game) fireWeapon(i *Iter, input *Input) {
+	w := WeaponKey.Get(i)
+	if sc := ShipControlKey.Get(i); sc.SwitchWeapon {
+		sc.SwitchWeapon = false
+		w.switchWeapon()
+	}
+	w.CoolDown -= input.Dt
+	if w.Kind < 0 || int(w.Kind) >= len(w.Ammo) {
+		return
+	}
+	if !ShipControlKey.Get(i).Secondary || w.CoolDown > 0 || w.Ammo[w.Kind] <= 0 {
+		return
+	}
+	w.Ammo[w.Kind]--
+	w.CoolDown = weaponSpecs[w.Kind].coolDown
+
+	// The host fires for predicted ships, when it receives their controls.
+	if i.Has(PredictedKey) {
+		return
+	}
+	if input.IsHost {
+		g.fire(i, w.Kind, input)
+		return
+	}
+	input.SendTo(0, &pb.FireWeapon{
+		Owner: *NetworkIdKey.Get(i),
+		Kind:  w.Kind,
+	})
+}
+
+// fire fires a secondary weapon of the kind from the ship the iter is pointing
+// at, which must be armed.  Only the host fires weapons.
+func (g *Game) fire(i *Iter, kind pb.WeaponKind, input *Input) {
+	switch kind {
+	case pb.WeaponKind_LASER:
+		g.fireLaser(i, input)
+	case pb.WeaponKind_MINE:
+		g.dropMine(i, input)
+	case pb.WeaponKind_HOMING:
+		g.shootMissile(i, g.homingTarget(i), input)
+	}
+}
+
+// shootMissile fires a missile from the ship the iter is pointing at, which
+// steers towards the ship with the target nid, if it isn't 0.
+func (g *Game) shootMissile(i *Iter, target uint64, input *Input) {
+	momentum := *MomentumKey.Get(i)
+	momentum.AddEqual(Vec2FromRadians(*RotKey.Get(i)).Scale(MissileSpeed))
+
+	ownerCid := int64(0)
+	if authority := AuthorityKey.Get(i); authority != nil {
+		ownerCid = *authority
+	}
+	team := int32(0)
+	if t := TeamKey.Get(i); t != nil {
+		team = *t
+	}
+
+	spawnMissile := &pb.SpawnMissile{
+		Nid:      g.NextNid(),
+		Owner:    *NetworkIdKey.Get(i),
+		OwnerCid: ownerCid,
+		Team:     team,
+		Pos:      PosKey.Get(i).ToProto(),
+		Momentum: momentum.ToProto(),
+		Rot:      *RotKey.Get(i),
+		Spin:     *SpinKey.Get(i),
+		Target:   target,
+	}
+	// The host creates the missile now, rather than when the memo comes back to
+	// it, so that a missile fired in a resimulated tick flies from that tick.
+	g.spawnMissile(spawnMissile, input)
+	input.BroadcastOthers(spawnMissile)
+}
+
+func (g *Game) spawnMissile(spawnMissile *pb.SpawnMissile, input *Input) {
+	i := g.E.NewIter()
+	if input.IsHost {
+		i.Require(NetworkTransmitKey)
+		i.Require(CanExplodeKey)
+		i.Require(TimedExplodeKey)
+	} else {
+		i.Require(NetworkReceiveKey)
+	}
+
+	i.Require(NetworkIdKey)
+	i.Require(PosKey)
+	i.Require(MomentumKey)
+	i.Require(RotKey)
+	i.Require(SpinKey)
+	i.Require(SpriteKey)
+	i.Require(AffectedByGravityKey)
+	i.Require(LookupKey)
+	i.Require(CanExplodeKey)
+	i.Require(MissileDetailsKey)
+	i.Require(TeamKey)
+
+	i.New()
+
+	if input.IsHost {
+		*TimedExplodeKey.Get(i) = 2
+	}
+
+	*NetworkIdKey.Get(i) = spawnMissile.Nid
+	g.NetworkIds[spawnMissile.Nid] = LookupKey.Get(i)
+	*PosKey.Get(i) = Vec2FromProto(spawnMissile.Pos)
+	*MomentumKey.Get(i) = Vec2FromProto(spawnMissile.Momentum)
+	*RotKey.Get(i) = spawnMissile.Rot
+	*SpinKey.Get(i) = spawnMissile.Spin
+	*SpriteKey.Get(i) = SpriteMissile
+	MissileDetailsKey.Get(i).Owner = g.NetworkIds[spawnMissile.Owner]
+	MissileDetailsKey.Get(i).OwnerCid = spawnMissile.OwnerCid
+	MissileDetailsKey.Get(i).Target = g.NetworkIds[spawnMissile.Target]
+	*TeamKey.Get(i) = spawnMissile.Team
+}
+
+// homingTarget returns the nid of the nearest enemy ship in front of the ship
+// the iter is pointing at, or 0 if there isn't one.
+func (g *Game) homingTarget(i *Iter) uint64 {
+	pos := *PosKey.Get(i)
+	rot := *RotKey.Get(i)
+	team := *TeamKey.Get(i)
+
+	target := uint64(0)
+	closest := float32(homingRange)
+	other := g.E.NewIter()
+	other.Require(PosKey)
+	other.Require(ShipControlKey)
+	other.Require(NetworkIdKey)
+	other.Require(LookupKey)
+	other.Require(TeamKey)
+	for other.Next() {
+		if LookupKey.Get(other) == LookupKey.Get(i) || g.harmless(team, *TeamKey.Get(other)) {
+			continue
+		}
+		diff := PosKey.Get(other).Sub(pos)
+		off := math.Remainder(float64(radians(diff)-rot), 2*math.Pi)
+		if off > homingCone || off < -homingCone {
+			continue
+		}
+		if dist := diff.Length(); dist < closest {
+			closest = dist
+			target = *NetworkIdKey.Get(other)
+		}
+	}
+	return target
+}
+
+// steerMissiles turns homing missiles towards their targets, for missileThrust
+// to push them along.
+func (g *Game) steerMissiles(input *Input) {
+	i := g.E.NewIter()
+	i.Require(PosKey)
+	i.Require(RotKey)
+	i.Require(SpinKey)
+	i.Require(MissileDetailsKey)
+	target := g.E.NewIter()
+	target.Require(PosKey)
+
+	for i.Next() {
+		details := MissileDetailsKey.Get(i)
+		if details.Target == 0 {
+			continue
+		}
+		if !target.Get(details.Target) {
+			// The target is gone, so fly straight from now on.
+			details.Target = 0
+			*SpinKey.Get(i) = 0
+			continue
+		}
+		diff := PosKey.Get(target).Sub(*PosKey.Get(i))
+		off := float32(math.Remainder(float64(radians(diff)-*RotKey.Get(i)), 2*math.Pi))
+
+		// Turn fast enough to face the target within a tenth of a second, but
+		// no faster than the turn rate.
+		spin := off * 10
+		if spin > HomingTurnRate {
+			spin = HomingTurnRate
+		}
+		if spin < -HomingTurnRate {
+			spin = -HomingTurnRate
+		}
+		*SpinKey.Get(i) = spin
+	}
+}
+
+// fireLaser hits the first thing in front of the ship the iter is pointing at,
+// which must be armed.
+func (g *Game) fireLaser(i *Iter, input *Input) {
+	from := *PosKey.Get(i)
+	dir := Vec2FromRadians(*RotKey.Get(i))
+	cid := *AuthorityKey.Get(i)
+	team := *TeamKey.Get(i)
+
+	hit := EntityID(0)
+	hitDist := float32(LaserRange)
+	other := g.E.NewIter()
+	other.Require(PosKey)
+	other.Require(LookupKey)
+	other.Require(CanExplodeKey)
+	other.Require(NetworkIdKey)
+	for other.Next() {
+		if LookupKey.Get(other) == LookupKey.Get(i) {
+			continue
+		}
+		if t := TeamKey.Get(other); t != nil && g.harmless(team, *t) {
+			continue
+		}
+		if dist, ok := rayHit(from, dir, *PosKey.Get(other), laserHitRadius); ok && dist < hitDist {
+			hit = LookupKey.Get(other)
+			hitDist = dist
+		}
+	}
+
+	to := from.Add(dir.Scale(hitDist))
+	input.BroadcastAll(&pb.LaserBeam{
+		From: from.ToProto(),
+		To:   to.ToProto(),
+	})
+
+	if other.Get(hit) {
+		if other.Has(HealthKey) && g.damage(other, LaserDamage, input) {
+			return
+		}
+		g.destroy(other, hit, cid, input)
+	}
+}
+
+// rayHit returns how far along the ray from start, going in the unit direction
+// dir, it first comes within radius of center.
+func rayHit(start Vec2, dir Vec2, center Vec2, radius float32) (float32, bool) {
+	toCenter := center.Sub(start)
+	along := toCenter.Dot(dir)
+	missBy := toCenter.Dot(toCenter) - along*along
+	if missBy > radius*radius {
+		return 0, false
+	}
+	half := float32(math.Sqrt(float64(radius*radius - missBy)))
+	if along+half < 0 {
+		return 0, false
+	}
+	if along-half < 0 {
+		// The ray starts inside the circle.
+		return 0, true
+	}
+	return along - half, true
+}
+
+// dropMine drops a mine behind the ship the iter is pointing at, which must be
+// armed.
+func (g *Game) dropMine(i *Iter, input *Input) {
+	back := Vec2FromRadians(*RotKey.Get(i)).Scale(-1)
+	pos := PosKey.Get(i).Add(back.Scale(0.8))
+	momentum := MomentumKey.Get(i).Add(back)
+	input.BroadcastAll(&pb.SpawnMine{
+		Nid:      g.NextNid(),
+		OwnerCid: *AuthorityKey.Get(i),
+		Team:     *TeamKey.Get(i),
+		Pos:      pos.ToProto(),
+		Momentum: momentum.ToProto(),
+	})
+}
+
+func (g *Game) spawnLaserBeam(from Vec2, to Vec2) {
+	ip := g.E.NewIter()
+	ip.Require(PosKey)
+	ip.Require(PointRenderKey)
+	ip.Require(MomentumKey)
+	ip.Require(TimedDestroyKey)
+
+	const spacing = 0.1
+	diff := to.Sub(from)
+	length := diff.Length()
+	for d := float32(0); d < length; d += spacing {
+		ip.New()
+		*PosKey.Get(ip) = from.Add(diff.Scale(d / length))
+		*MomentumKey.Get(ip) = Vec2FromRadians(g.Rand.Float32() * 2 * math.Pi).Scale(g.Rand.Float32() * 0.3)
+		*TimedDestroyKey.Get(ip) = g.Rand.Float32()*0.3 + 0.2
+	}
+}
+
+// triggerMines blows up armed mines which have a ship close by.
+func (g *Game) triggerMines(input *Input) {
+	i := g.E.NewIter()
+	i.Require(MineDetailsKey)
+	i.Require(PosKey)
+	i.Require(MomentumKey)
+	i.Require(LookupKey)
+	i.Require(TeamKey)
+	i.Require(NetworkTransmitKey)
+	other := g.E.NewIter()
+
+	for i.Next() {
+		details := MineDetailsKey.Get(i)
+		if details.Arming > 0 {
+			details.Arming -= input.Dt
+			continue
+		}
+
+		for _, id := range g.QueryRadius(*PosKey.Get(i), MineTriggerRadius) {
+			if !other.Get(id) || !other.Has(ShipControlKey) {
+				continue
+			}
+			if t := TeamKey.Get(other); t != nil && g.harmless(*TeamKey.Get(i), *t) {
+				continue
+			}
+			input.BroadcastOthers(&pb.DestroyEvent{
+				Nid: *NetworkIdKey.Get(i),
+			})
+			input.BroadcastAll(&pb.SpawnExplosion{
+				Nid:      *NetworkIdKey.Get(i),
+				Pos:      PosKey.Get(i).ToProto(),
+				Momentum: MomentumKey.Get(i).ToProto(),
+				CausedBy: details.OwnerCid,
+			})
+			g.E.Commands.Remove(LookupKey.Get(i))
+			break
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Replay runs a match recorded by the dedicated server (see RECORD_FILE) through
+// a headless game, and reports how it ended.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/laremere/space-agon/game"
+)
+
+var (
+	verbose  = flag.Bool("v", false, "log every memo the game sends")
+	snapshot = flag.String("snapshot", "", "write a snapshot of the final state to this file")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: replay [flags] recording")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	var afterStep func(g *game.Game, input *game.Input)
+	if *verbose {
+		afterStep = func(g *game.Game, input *game.Input) {
+			for _, memo := range input.MemosOut {
+				log.Printf("tick %d: %v", g.Tick, memo)
+			}
+		}
+	}
+
+	g, err := game.Replay(f, afterStep)
+	if err != nil {
+		// Report what was replayed anyway, a recording cut off by a crash is one
+		// of the more interesting ones.
+		log.Println("Replay stopped early:", err)
+	}
+
+	fmt.Printf("Ticks: %d\n", g.Tick)
+	fmt.Printf("Networked entities: %d\n", len(g.NetworkIds))
+	fmt.Print(g.Profiler)
+
+	if *snapshot != "" {
+		b, err := g.Snapshot()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = ioutil.WriteFile(*snapshot, b, 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}